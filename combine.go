@@ -0,0 +1,221 @@
+package xsync
+
+import (
+	"errors"
+	"iter"
+	"reflect"
+)
+
+// All returns a future that completes once all of futs have
+// completed. Its value is a slice of the values of futs in the same
+// order as they were passed.
+func All[T any](futs ...*Future[T]) *Future[[]T] {
+	future, complete := NewFuture[[]T]()
+	go func() {
+		vals := make([]T, 0, len(futs))
+		for _, f := range futs {
+			vals = append(vals, f.Get())
+		}
+		complete(vals)
+	}()
+	return future
+}
+
+// AllErr is like [All] but for fallible futures. If any of futs
+// completes with an error, the returned future is immediately
+// completed with that error without waiting for the rest of futs.
+func AllErr[T any](futs ...*ErrFuture[T]) *ErrFuture[[]T] {
+	future, complete := NewErrFuture[[]T]()
+	go func() {
+		for i := range awaitEach(errDones(futs)) {
+			if _, err := futs[i].Get(); err != nil {
+				complete(nil, err)
+				return
+			}
+		}
+
+		vals := make([]T, 0, len(futs))
+		for _, f := range futs {
+			vals = append(vals, f.val)
+		}
+		complete(vals, nil)
+	}()
+	return future
+}
+
+// First returns a future that completes with the value of whichever
+// of futs completes first. If futs is empty, the returned future
+// never completes.
+func First[T any](futs ...*Future[T]) *Future[T] {
+	future, complete := NewFuture[T]()
+	if len(futs) == 0 {
+		return future
+	}
+
+	go func() {
+		dones := make([]<-chan struct{}, 0, len(futs))
+		for _, f := range futs {
+			dones = append(dones, f.Done())
+		}
+		for i := range awaitEach(dones) {
+			complete(futs[i].Get())
+			return
+		}
+	}()
+	return future
+}
+
+// FirstErr is like [First] but for fallible futures. The returned
+// future completes with the value and error of whichever of futs
+// completes first, regardless of whether or not that was an error.
+func FirstErr[T any](futs ...*ErrFuture[T]) *ErrFuture[T] {
+	future, complete := NewErrFuture[T]()
+	if len(futs) == 0 {
+		return future
+	}
+
+	go func() {
+		for i := range awaitEach(errDones(futs)) {
+			complete(futs[i].Get())
+			return
+		}
+	}()
+	return future
+}
+
+// Any returns a future that completes with the value of whichever of
+// futs first completes successfully. If all of futs complete with
+// errors, the returned future completes with all of those errors
+// joined via [errors.Join] in the order that futs were passed. If
+// futs is empty, the returned future never completes.
+func Any[T any](futs ...*ErrFuture[T]) *ErrFuture[T] {
+	future, complete := NewErrFuture[T]()
+	if len(futs) == 0 {
+		return future
+	}
+
+	go func() {
+		for i := range awaitEach(errDones(futs)) {
+			if v, err := futs[i].Get(); err == nil {
+				complete(v, nil)
+				return
+			}
+		}
+
+		errs := make([]error, 0, len(futs))
+		for _, f := range futs {
+			errs = append(errs, f.err)
+		}
+		var zero T
+		complete(zero, errors.Join(errs...))
+	}()
+	return future
+}
+
+// Then returns a future that completes with the value of the future
+// returned by fn, which is called with the value of f once f
+// completes.
+func Then[T, R any](f *Future[T], fn func(T) *Future[R]) *Future[R] {
+	future, complete := NewFuture[R]()
+	go func() { complete(fn(f.Get()).Get()) }()
+	return future
+}
+
+// ThenErr is like [Then] but for fallible futures. If f completes
+// with an error, fn is not called and the returned future completes
+// with that error instead.
+func ThenErr[T, R any](f *ErrFuture[T], fn func(T) *ErrFuture[R]) *ErrFuture[R] {
+	future, complete := NewErrFuture[R]()
+	go func() {
+		v, err := f.Get()
+		if err != nil {
+			var zero R
+			complete(zero, err)
+			return
+		}
+		complete(fn(v).Get())
+	}()
+	return future
+}
+
+// Transform returns a future that completes with the result of
+// calling fn on the value of f once f completes. It is the equivalent
+// of a map operation on futures but is named so as not to conflict
+// with [Map].
+func Transform[T, R any](f *Future[T], fn func(T) R) *Future[R] {
+	future, complete := NewFuture[R]()
+	go func() { complete(fn(f.Get())) }()
+	return future
+}
+
+// TransformErr is like [Transform] but for fallible futures. If f
+// completes with an error, fn is not called and the returned future
+// completes with that error instead.
+func TransformErr[T, R any](f *ErrFuture[T], fn func(T) (R, error)) *ErrFuture[R] {
+	future, complete := NewErrFuture[R]()
+	go func() {
+		v, err := f.Get()
+		if err != nil {
+			var zero R
+			complete(zero, err)
+			return
+		}
+		complete(fn(v))
+	}()
+	return future
+}
+
+// Collect returns an iterator that yields the values of the futures
+// yielded by seq in the order that they complete. The futures are
+// all gathered from seq before any values are yielded.
+func Collect[T any](seq iter.Seq[*Future[T]]) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		var futs []*Future[T]
+		var dones []<-chan struct{}
+		for f := range seq {
+			futs = append(futs, f)
+			dones = append(dones, f.Done())
+		}
+
+		for i := range awaitEach(dones) {
+			if !yield(futs[i].Get()) {
+				return
+			}
+		}
+	}
+}
+
+func errDones[T any](futs []*ErrFuture[T]) []<-chan struct{} {
+	dones := make([]<-chan struct{}, 0, len(futs))
+	for _, f := range futs {
+		dones = append(dones, f.Done())
+	}
+	return dones
+}
+
+// awaitEach returns an iterator that yields the indices of dones in
+// the order that the channels are closed.
+func awaitEach(dones []<-chan struct{}) iter.Seq[int] {
+	return func(yield func(int) bool) {
+		rcases := make([]reflect.SelectCase, 0, len(dones))
+		indices := make([]int, 0, len(dones))
+		for i, done := range dones {
+			rcases = append(rcases, reflect.SelectCase{
+				Dir:  reflect.SelectRecv,
+				Chan: reflect.ValueOf(done),
+			})
+			indices = append(indices, i)
+		}
+
+		for len(rcases) > 0 {
+			i, _, _ := reflect.Select(rcases)
+			if !yield(indices[i]) {
+				return
+			}
+
+			last := len(rcases) - 1
+			rcases[i], indices[i] = rcases[last], indices[last]
+			rcases, indices = rcases[:last], indices[:last]
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package xsync_test
+
+import (
+	"errors"
+	"io"
+	"slices"
+	"strconv"
+	"testing"
+
+	"deedles.dev/xsync"
+)
+
+func TestAll(t *testing.T) {
+	f1, c1 := xsync.NewFuture[int]()
+	f2, c2 := xsync.NewFuture[int]()
+	all := xsync.All(f1, f2)
+
+	c2(2)
+	c1(1)
+	if vals := all.Get(); !slices.Equal(vals, []int{1, 2}) {
+		t.Fatal(vals)
+	}
+}
+
+func TestAllErr(t *testing.T) {
+	f1, _ := xsync.NewErrFuture[int]()
+	f2, c2 := xsync.NewErrFuture[int]()
+	all := xsync.AllErr(f1, f2)
+
+	c2(0, io.EOF)
+	if _, err := all.Get(); err != io.EOF {
+		t.Fatalf("expected EOF but got %v", err)
+	}
+}
+
+func TestFirst(t *testing.T) {
+	f1, _ := xsync.NewFuture[int]()
+	f2, c2 := xsync.NewFuture[int]()
+	first := xsync.First(f1, f2)
+
+	c2(2)
+	if v := first.Get(); v != 2 {
+		t.Fatal(v)
+	}
+}
+
+func TestAny(t *testing.T) {
+	f1, c1 := xsync.NewErrFuture[int]()
+	f2, c2 := xsync.NewErrFuture[int]()
+	f3, c3 := xsync.NewErrFuture[int]()
+	first := xsync.FirstErr(f1, f2, f3)
+	anyf := xsync.Any(f1, f2, f3)
+
+	c1(0, io.EOF)
+	if _, err := first.Get(); err != io.EOF {
+		t.Fatalf("expected EOF but got %v", err)
+	}
+	c2(2, nil)
+	if v, err := anyf.Get(); err != nil || v != 2 {
+		t.Fatal(v, err)
+	}
+	c3(3, nil)
+
+	f4, c4 := xsync.NewErrFuture[int]()
+	f5, c5 := xsync.NewErrFuture[int]()
+	c5(0, io.ErrUnexpectedEOF)
+	c4(0, io.EOF)
+	_, err := xsync.Any(f4, f5).Get()
+	if !errors.Is(err, io.EOF) || !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("expected joined errors but got %v", err)
+	}
+}
+
+func TestCollect(t *testing.T) {
+	f1, c1 := xsync.NewFuture[int]()
+	f2, c2 := xsync.NewFuture[int]()
+	f3, c3 := xsync.NewFuture[int]()
+	next := map[int]func(){2: func() { c3(3) }, 3: func() { c1(1) }, 1: func() {}}
+
+	c2(2)
+	var got []int
+	for v := range xsync.Collect(slices.Values([]*xsync.Future[int]{f1, f2, f3})) {
+		got = append(got, v)
+		next[v]()
+	}
+	if !slices.Equal(got, []int{2, 3, 1}) {
+		t.Fatal(got)
+	}
+}
+
+func TestFromChan(t *testing.T) {
+	c := make(chan int, 2)
+	c <- 1
+	c <- 2
+
+	f := xsync.FromChan(c)
+	if v := <-f.Chan(); v != 1 {
+		t.Fatal(v)
+	}
+	if v := f.Get(); v != 1 {
+		t.Fatal(v)
+	}
+}
+
+func TestThenTransform(t *testing.T) {
+	f, complete := xsync.NewFuture[int]()
+	then := xsync.Then(f, func(v int) *xsync.Future[int] {
+		return xsync.Go(func() int { return v * 2 })
+	})
+	mapped := xsync.Transform(then, strconv.Itoa)
+
+	complete(3)
+	if v := mapped.Get(); v != "6" {
+		t.Fatal(v)
+	}
+}
+
+func TestThenTransformErr(t *testing.T) {
+	f, complete := xsync.NewErrFuture[int]()
+	var called bool
+	then := xsync.ThenErr(f, func(v int) *xsync.ErrFuture[int] {
+		called = true
+		return xsync.GoErr(func() (int, error) { return v, nil })
+	})
+	mapped := xsync.TransformErr(then, func(v int) (string, error) {
+		called = true
+		return strconv.Itoa(v), nil
+	})
+
+	complete(3, io.EOF)
+	if _, err := mapped.Get(); err != io.EOF {
+		t.Fatalf("expected EOF but got %v", err)
+	}
+	if called {
+		t.Fatal("continuation called despite error")
+	}
+}
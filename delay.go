@@ -0,0 +1,200 @@
+package xsync
+
+import (
+	"runtime"
+	"sync"
+	"time"
+)
+
+// A Clock provides the current time and timers. It allows types that
+// depend on the passage of time, such as [DelayQueue], to be tested
+// deterministically.
+type Clock interface {
+	Now() time.Time
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer is the interface of timers created by a [Clock]. Its methods
+// behave the same as those of [time.Timer].
+type Timer interface {
+	C() <-chan time.Time
+	Reset(d time.Duration) bool
+	Stop() bool
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now() }
+
+func (realClock) NewTimer(d time.Duration) Timer {
+	return realTimer{time.NewTimer(d)}
+}
+
+type realTimer struct {
+	*time.Timer
+}
+
+func (t realTimer) C() <-chan time.Time { return t.Timer.C }
+
+// A DelayQueue holds values until a scheduled time and then yields
+// them in the order that they became available. A zero-value
+// DelayQueue is ready to use.
+//
+// The caveats about garbage collection described for [Queue] also
+// apply to DelayQueue.
+type DelayQueue[T any] struct {
+	// Clock is used to determine when values become available. If it
+	// is nil, the system clock is used. It must not be modified after
+	// the DelayQueue has been initialized.
+	Clock Clock
+
+	start sync.Once
+	stop  func()
+	clock Clock
+
+	get  chan T
+	req  chan func(*delayRunner[T])
+	exit chan struct{}
+}
+
+func (q *DelayQueue[T]) init() {
+	q.start.Do(func() {
+		q.get = make(chan T)
+		q.req = make(chan func(*delayRunner[T]))
+		q.exit = make(chan struct{})
+
+		done := make(chan struct{})
+		q.stop = sync.OnceFunc(func() { close(done) })
+
+		q.clock = q.Clock
+		if q.clock == nil {
+			q.clock = realClock{}
+		}
+
+		runner := delayRunner[T]{
+			get:   q.get,
+			req:   q.req,
+			exit:  q.exit,
+			clock: q.clock,
+			buf: heapBuffer[delayed[T]]{
+				less: func(a, b delayed[T]) bool { return a.at.Before(b.at) },
+			},
+		}
+		go runner.run(done)
+
+		runtime.AddCleanup(q, func(stop func()) { stop() }, q.stop)
+	})
+}
+
+// Stop stops the DelayQueue. Values that are still in the queue are
+// discarded. It is safe to call Stop more than once.
+func (q *DelayQueue[T]) Stop() {
+	q.init()
+	q.stop()
+}
+
+// PushAt adds v to the queue such that it will become available at
+// time t. If the queue has been stopped, it returns [ErrQueueClosed].
+func (q *DelayQueue[T]) PushAt(v T, t time.Time) error {
+	ok := q.do(func(r *delayRunner[T]) {
+		r.buf.push(delayed[T]{at: t, val: v})
+	})
+	if !ok {
+		return ErrQueueClosed
+	}
+	return nil
+}
+
+// PushAfter adds v to the queue such that it will become available
+// once d has elapsed. If the queue has been stopped, it returns
+// [ErrQueueClosed].
+func (q *DelayQueue[T]) PushAfter(v T, d time.Duration) error {
+	q.init()
+	return q.PushAt(v, q.clock.Now().Add(d))
+}
+
+// Pop returns a channel that yields values from the queue once their
+// scheduled time has arrived. The channel will be closed when the
+// queue is stopped.
+func (q *DelayQueue[T]) Pop() <-chan T {
+	q.init()
+	return q.get
+}
+
+// do runs f in the DelayQueue's runner goroutine and waits for it to
+// return. It returns false without running f if the runner has
+// exited.
+func (q *DelayQueue[T]) do(f func(*delayRunner[T])) bool {
+	q.init()
+	return runnerDo(q.req, q.exit, f)
+}
+
+type delayed[T any] struct {
+	at  time.Time
+	val T
+}
+
+type delayRunner[T any] struct {
+	get  chan T
+	req  chan func(*delayRunner[T])
+	exit chan struct{}
+
+	clock Clock
+	timer Timer
+	wake  time.Time
+
+	buf heapBuffer[delayed[T]]
+}
+
+func (q *delayRunner[T]) run(done <-chan struct{}) {
+	defer func() {
+		close(q.get)
+		close(q.exit)
+		if q.timer != nil {
+			q.timer.Stop()
+		}
+	}()
+
+	for {
+		var get chan T
+		var wait <-chan time.Time
+		next := q.buf.peek()
+		if q.buf.len() > 0 {
+			if d := next.at.Sub(q.clock.Now()); d > 0 {
+				wait = q.wait(next.at, d)
+			} else {
+				get = q.get
+			}
+		}
+
+		select {
+		case <-done:
+			return
+
+		case f := <-q.req:
+			if !runnerServe(done, q, f) {
+				return
+			}
+
+		case get <- next.val:
+			q.buf.pop()
+
+		case <-wait:
+			q.wake = time.Time{}
+		}
+	}
+}
+
+// wait arranges for the runner's single timer to fire at t, which is
+// d from now, and returns the timer's channel.
+func (q *delayRunner[T]) wait(t time.Time, d time.Duration) <-chan time.Time {
+	switch {
+	case q.timer == nil:
+		q.timer = q.clock.NewTimer(d)
+	case !t.Equal(q.wake):
+		q.timer.Reset(d)
+	}
+
+	q.wake = t
+	return q.timer.C()
+}
@@ -0,0 +1,137 @@
+package xsync_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"deedles.dev/xsync"
+)
+
+type fakeClock struct {
+	m      sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.m.Lock()
+	defer c.m.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) NewTimer(d time.Duration) xsync.Timer {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	t := &fakeTimer{clock: c, c: make(chan time.Time, 1)}
+	t.resetLocked(d)
+	c.timers = append(c.timers, t)
+	return t
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	c.now = c.now.Add(d)
+	for _, t := range c.timers {
+		if t.active && !t.at.After(c.now) {
+			t.active = false
+			t.c <- c.now
+		}
+	}
+}
+
+type fakeTimer struct {
+	clock  *fakeClock
+	c      chan time.Time
+	at     time.Time
+	active bool
+}
+
+func (t *fakeTimer) C() <-chan time.Time { return t.c }
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	t.clock.m.Lock()
+	defer t.clock.m.Unlock()
+	return t.resetLocked(d)
+}
+
+func (t *fakeTimer) resetLocked(d time.Duration) bool {
+	select {
+	case <-t.c:
+	default:
+	}
+
+	active := t.active
+	t.at = t.clock.now.Add(d)
+	t.active = true
+	if d <= 0 {
+		t.active = false
+		t.c <- t.clock.now
+	}
+	return active
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.m.Lock()
+	defer t.clock.m.Unlock()
+
+	active := t.active
+	t.active = false
+	return active
+}
+
+func TestDelayQueue(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	q := xsync.DelayQueue[string]{Clock: clock}
+	defer q.Stop()
+
+	q.PushAfter("b", 2*time.Second)
+	q.PushAfter("a", time.Second)
+	q.PushAt("c", clock.Now().Add(2*time.Second))
+
+	select {
+	case v := <-q.Pop():
+		t.Fatalf("got %q before it was due", v)
+	default:
+	}
+
+	clock.Advance(time.Second)
+	if v := <-q.Pop(); v != "a" {
+		t.Fatal(v)
+	}
+	select {
+	case v := <-q.Pop():
+		t.Fatalf("got %q before it was due", v)
+	default:
+	}
+
+	clock.Advance(time.Second)
+	if v := <-q.Pop(); v != "b" {
+		t.Fatal(v)
+	}
+	if v := <-q.Pop(); v != "c" {
+		t.Fatal(v)
+	}
+}
+
+func TestDelayQueueRealClock(t *testing.T) {
+	var q xsync.DelayQueue[int]
+	defer q.Stop()
+
+	start := time.Now()
+	q.PushAfter(1, 10*time.Millisecond)
+	if v := <-q.Pop(); v != 1 {
+		t.Fatal(v)
+	}
+	if d := time.Since(start); d < 10*time.Millisecond {
+		t.Fatalf("value available after only %v", d)
+	}
+
+	q.Stop()
+	if err := q.PushAfter(2, 0); err != xsync.ErrQueueClosed {
+		t.Fatalf("expected ErrQueueClosed but got %v", err)
+	}
+}
@@ -0,0 +1,236 @@
+package xsync
+
+import (
+	"iter"
+	"runtime"
+	"sync"
+
+	"deedles.dev/xsync/internal/list"
+)
+
+// A Stack is like a [Queue] but returns values in LIFO order. A zero
+// value Stack is ready to use.
+//
+// The caveats about garbage collection described for [Queue] also
+// apply to Stack.
+type Stack[T any] struct {
+	start sync.Once
+	q     Queue[T]
+}
+
+func (s *Stack[T]) init() {
+	s.start.Do(func() {
+		s.q.newBuf = func() queueBuffer[T] { return new(stackBuffer[T]) }
+	})
+}
+
+func (s *Stack[T]) Stop() {
+	s.init()
+	s.q.Stop()
+}
+
+// Push returns a channel that pushes values sent to it onto the
+// stack. Closing this channel will cause the channel returned by Pop
+// to be closed once the Stack's contents are emptied, similar to how
+// a regular channel works.
+func (s *Stack[T]) Push() chan<- T {
+	s.init()
+	return s.q.Push()
+}
+
+// Pop returns a channel that yields the most recently pushed value
+// when one is available. The channel will be closed when the Stack is
+// stopped.
+func (s *Stack[T]) Pop() <-chan T {
+	s.init()
+	return s.q.Pop()
+}
+
+// All returns an iterator over all values currently in the stack,
+// starting with the most recently pushed. Receiving from the channel
+// will empty the stack. The channel will block until there is at
+// least one value in the stack.
+//
+// Like the channel returned by [Pop], it will be closed when the
+// Stack is stopped.
+func (s *Stack[T]) All() <-chan iter.Seq[T] {
+	s.init()
+	return s.q.All()
+}
+
+// stackBuffer is a LIFO queueBuffer.
+type stackBuffer[T any] struct {
+	vals []T
+}
+
+func (b *stackBuffer[T]) len() int { return len(b.vals) }
+
+func (b *stackBuffer[T]) push(v T) { b.vals = append(b.vals, v) }
+
+func (b *stackBuffer[T]) peek() (v T) {
+	if len(b.vals) == 0 {
+		return v
+	}
+	return b.vals[len(b.vals)-1]
+}
+
+func (b *stackBuffer[T]) pop() {
+	if len(b.vals) == 0 {
+		return
+	}
+
+	var zero T
+	b.vals[len(b.vals)-1] = zero
+	b.vals = b.vals[:len(b.vals)-1]
+}
+
+func (b *stackBuffer[T]) all() iter.Seq[T] {
+	vals := b.vals
+	return func(yield func(T) bool) {
+		for i := len(vals) - 1; i >= 0; i-- {
+			if !yield(vals[i]) {
+				return
+			}
+		}
+	}
+}
+
+func (b *stackBuffer[T]) clear() {
+	b.vals = nil
+}
+
+// A Deque is a double-ended queue that concurrently collects values
+// and allows them to be added and removed at either end. A zero value
+// Deque is ready to use.
+//
+// The caveats about garbage collection described for [Queue] also
+// apply to Deque.
+type Deque[T any] struct {
+	start sync.Once
+	stop  func()
+
+	pushFront chan T
+	pushBack  chan T
+	popFront  chan T
+	popBack   chan T
+}
+
+func (d *Deque[T]) init() {
+	d.start.Do(func() {
+		d.pushFront = make(chan T)
+		d.pushBack = make(chan T)
+		d.popFront = make(chan T)
+		d.popBack = make(chan T)
+
+		done := make(chan struct{})
+		d.stop = sync.OnceFunc(func() { close(done) })
+
+		runner := dequeRunner[T]{
+			pushFront: d.pushFront,
+			pushBack:  d.pushBack,
+			popFront:  d.popFront,
+			popBack:   d.popBack,
+		}
+		go runner.run(done)
+
+		runtime.AddCleanup(d, func(stop func()) { stop() }, d.stop)
+	})
+}
+
+func (d *Deque[T]) Stop() {
+	d.init()
+	d.stop()
+}
+
+// PushFront returns a channel that adds values sent to it to the
+// front of the Deque. Once both this channel and the one returned by
+// PushBack have been closed, the channels returned by PopFront and
+// PopBack will be closed once the Deque's contents are emptied.
+func (d *Deque[T]) PushFront() chan<- T {
+	d.init()
+	return d.pushFront
+}
+
+// PushBack returns a channel that adds values sent to it to the back
+// of the Deque. See [PushFront] for the behavior of closing it.
+func (d *Deque[T]) PushBack() chan<- T {
+	d.init()
+	return d.pushBack
+}
+
+// PopFront returns a channel that yields values from the front of the
+// Deque when they are available. The channel will be closed when the
+// Deque is stopped.
+func (d *Deque[T]) PopFront() <-chan T {
+	d.init()
+	return d.popFront
+}
+
+// PopBack returns a channel that yields values from the back of the
+// Deque when they are available. The channel will be closed when the
+// Deque is stopped.
+func (d *Deque[T]) PopBack() <-chan T {
+	d.init()
+	return d.popBack
+}
+
+type dequeRunner[T any] struct {
+	pushFront chan T
+	pushBack  chan T
+	popFront  chan T
+	popBack   chan T
+}
+
+func (d *dequeRunner[T]) run(done <-chan struct{}) {
+	pushFront := d.pushFront
+	pushBack := d.pushBack
+
+	defer func() {
+		close(d.popFront)
+		close(d.popBack)
+		// Ensure that future attempts to send to the deque will fail.
+		if pushFront != nil {
+			close(pushFront)
+		}
+		if pushBack != nil {
+			close(pushBack)
+		}
+	}()
+
+	var ls list.Double[T]
+	for {
+		var popFront, popBack chan T
+		var front, back T
+		if head := ls.Head(); head != nil {
+			popFront, front = d.popFront, head.Val
+			popBack, back = d.popBack, ls.Tail().Val
+		} else if pushFront == nil && pushBack == nil {
+			return
+		}
+
+		select {
+		case <-done:
+			return
+
+		case v, ok := <-pushFront:
+			if !ok {
+				pushFront = nil
+				continue
+			}
+			ls.PushFront(v)
+
+		case v, ok := <-pushBack:
+			if !ok {
+				pushBack = nil
+				continue
+			}
+			ls.Push(v)
+
+		case popFront <- front:
+			ls.Remove(ls.Head())
+
+		case popBack <- back:
+			ls.Remove(ls.Tail())
+		}
+	}
+}
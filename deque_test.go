@@ -0,0 +1,53 @@
+package xsync_test
+
+import (
+	"slices"
+	"testing"
+
+	"deedles.dev/xsync"
+)
+
+func TestStack(t *testing.T) {
+	var s xsync.Stack[int]
+	defer s.Stop()
+
+	for i := range 3 {
+		s.Push() <- i
+	}
+	if v := <-s.Pop(); v != 2 {
+		t.Fatal(v)
+	}
+	s.Push() <- 3
+	if vals := slices.Collect(<-s.All()); !slices.Equal(vals, []int{3, 1, 0}) {
+		t.Fatal(vals)
+	}
+}
+
+func TestDeque(t *testing.T) {
+	var d xsync.Deque[int]
+	defer d.Stop()
+
+	d.PushBack() <- 1
+	d.PushBack() <- 2
+	d.PushFront() <- 0
+	d.PushFront() <- -1
+
+	if v := <-d.PopFront(); v != -1 {
+		t.Fatal(v)
+	}
+	if v := <-d.PopBack(); v != 2 {
+		t.Fatal(v)
+	}
+	if v := <-d.PopBack(); v != 1 {
+		t.Fatal(v)
+	}
+
+	close(d.PushFront())
+	close(d.PushBack())
+	if v := <-d.PopFront(); v != 0 {
+		t.Fatal(v)
+	}
+	if v, ok := <-d.PopBack(); ok {
+		t.Fatalf("expected closed channel but got %v", v)
+	}
+}
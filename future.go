@@ -1,11 +1,19 @@
 package xsync
 
-import "sync"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+)
 
 // A Future holds a value that might not be available yet.
 type Future[T any] struct {
-	done chan struct{}
-	val  T
+	start func()
+	done  chan struct{}
+	val   T
 }
 
 // NewFuture returns a new future and a function that completes that
@@ -29,14 +37,269 @@ func Go[T any](f func() T) *Future[T] {
 	return future
 }
 
+// FromChan returns a future that completes with the first value
+// received from c. If c is closed without yielding a value, the
+// future completes with the zero value of T.
+func FromChan[T any](c <-chan T) *Future[T] {
+	future, complete := NewFuture[T]()
+	go func() { complete(<-c) }()
+	return future
+}
+
+// Lazy returns a future that runs f concurrently to produce its value
+// but does not start doing so until the first time that one of the
+// future's methods is called.
+func Lazy[T any](f func() T) *Future[T] {
+	future, complete := NewFuture[T]()
+	future.start = sync.OnceFunc(func() {
+		go func() { complete(f()) }()
+	})
+	return future
+}
+
 // Done returns a channel that is closed when the future completes.
 func (f *Future[T]) Done() <-chan struct{} {
+	if f.start != nil {
+		f.start()
+	}
 	return f.done
 }
 
 // Get blocks, if necessary, until the future is completed and then
 // returns its value.
 func (f *Future[T]) Get() T {
-	<-f.done
+	<-f.Done()
 	return f.val
 }
+
+// Chan returns a buffered channel that will yield the future's value
+// once when the future completes. Each call returns a new channel.
+func (f *Future[T]) Chan() <-chan T {
+	c := make(chan T, 1)
+	go func() { c <- f.Get() }()
+	return c
+}
+
+// GetContext is like [Get] but returns early if ctx is canceled
+// before the future completes. In that case, the context's cause is
+// returned.
+func (f *Future[T]) GetContext(ctx context.Context) (val T, err error) {
+	select {
+	case <-ctx.Done():
+		return val, context.Cause(ctx)
+	case <-f.Done():
+		return f.val, nil
+	}
+}
+
+// GetTimeout is like [GetContext] but gives up after d has elapsed,
+// returning [context.DeadlineExceeded].
+func (f *Future[T]) GetTimeout(d time.Duration) (T, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), d)
+	defer cancel()
+	return f.GetContext(ctx)
+}
+
+// TryGet returns the future's value without blocking. If the future
+// has not completed yet, it returns false as the second return.
+func (f *Future[T]) TryGet() (val T, ok bool) {
+	select {
+	case <-f.Done():
+		return f.val, true
+	default:
+		return val, false
+	}
+}
+
+// An ErrFuture is like a [Future] but completes with either a value
+// or an error.
+type ErrFuture[T any] struct {
+	done chan struct{}
+	val  T
+	err  error
+}
+
+// NewErrFuture returns a new fallible future and a function that
+// completes that future with the given value and error. The returned
+// complete function becomes a no-op after the first usage.
+func NewErrFuture[T any]() (f *ErrFuture[T], complete func(T, error)) {
+	var once sync.Once
+	f = &ErrFuture[T]{done: make(chan struct{})}
+	return f, func(val T, err error) {
+		once.Do(func() {
+			f.val = val
+			f.err = err
+			close(f.done)
+		})
+	}
+}
+
+// GoErr runs f concurrently, yielding its results via the returned
+// [ErrFuture]. If f panics, the panic is recovered and the future is
+// completed with a [*PanicError] describing it.
+func GoErr[T any](f func() (T, error)) *ErrFuture[T] {
+	future, complete := NewErrFuture[T]()
+	go func() { complete(catch(f)) }()
+	return future
+}
+
+// Done returns a channel that is closed when the future completes.
+func (f *ErrFuture[T]) Done() <-chan struct{} {
+	return f.done
+}
+
+// Get blocks, if necessary, until the future is completed and then
+// returns its value and error.
+func (f *ErrFuture[T]) Get() (T, error) {
+	<-f.done
+	return f.val, f.err
+}
+
+// GetContext is like [Get] but returns early if ctx is canceled
+// before the future completes. In that case, the context's cause is
+// returned.
+func (f *ErrFuture[T]) GetContext(ctx context.Context) (val T, err error) {
+	select {
+	case <-ctx.Done():
+		return val, context.Cause(ctx)
+	case <-f.done:
+		return f.val, f.err
+	}
+}
+
+// GetTimeout is like [GetContext] but gives up after d has elapsed,
+// returning [context.DeadlineExceeded].
+func (f *ErrFuture[T]) GetTimeout(d time.Duration) (T, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), d)
+	defer cancel()
+	return f.GetContext(ctx)
+}
+
+// TryGet returns the future's value and error without blocking. If
+// the future has not completed yet, it returns false as the third
+// return.
+func (f *ErrFuture[T]) TryGet() (val T, err error, ok bool) {
+	select {
+	case <-f.done:
+		return f.val, f.err, true
+	default:
+		return val, nil, false
+	}
+}
+
+// ErrCanceled is the error that a [CancelFuture] completes with when
+// it is canceled before its producer finishes.
+var ErrCanceled = errors.New("future canceled")
+
+// A CancelFuture is an [ErrFuture] that allows its consumers to
+// signal to its producer that its result is no longer needed.
+type CancelFuture[T any] struct {
+	*ErrFuture[T]
+
+	cancel   context.CancelCauseFunc
+	complete func(T, error)
+
+	m    sync.Mutex
+	refs int
+}
+
+// GoContext runs f concurrently, yielding its results via the
+// returned [CancelFuture]. The context passed to f is derived from
+// ctx and is canceled when the future is canceled, as well as after f
+// returns. If f panics, the panic is handled the same way as by
+// [GoErr].
+//
+// If the future is canceled before f returns, it is completed
+// immediately with the cause of the cancellation, which is
+// [ErrCanceled] unless ctx was canceled, and the eventual results of
+// f are discarded.
+func GoContext[T any](ctx context.Context, f func(context.Context) (T, error)) *CancelFuture[T] {
+	future, complete := NewErrFuture[T]()
+	ctx, cancel := context.WithCancelCause(ctx)
+	context.AfterFunc(ctx, func() {
+		var zero T
+		complete(zero, context.Cause(ctx))
+	})
+
+	go func() {
+		defer cancel(nil)
+
+		v, err := catch(func() (T, error) { return f(ctx) })
+		if ctx.Err() != nil {
+			var zero T
+			v, err = zero, context.Cause(ctx)
+		}
+		complete(v, err)
+	}()
+
+	return &CancelFuture[T]{
+		ErrFuture: future,
+		cancel:    cancel,
+		complete:  complete,
+	}
+}
+
+// Cancel cancels the future regardless of how many consumers are
+// attached to it. It is a no-op if the future has already completed.
+func (f *CancelFuture[T]) Cancel() {
+	f.cancel(ErrCanceled)
+
+	var zero T
+	f.complete(zero, ErrCanceled)
+}
+
+// Attach registers a consumer of the future and returns a function
+// that unregisters it. When every consumer that has been attached
+// has called its release function, the future is canceled. Calling a
+// release function more than once has no further effect.
+//
+// A future that never has any consumers attached is only canceled by
+// an explicit call to Cancel or by the cancellation of the context
+// it was created with.
+func (f *CancelFuture[T]) Attach() (release func()) {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	f.refs++
+	return sync.OnceFunc(func() {
+		f.m.Lock()
+		defer f.m.Unlock()
+
+		f.refs--
+		if f.refs == 0 {
+			f.Cancel()
+		}
+	})
+}
+
+// PanicError is the error produced when a function run by [GoErr]
+// panics.
+type PanicError struct {
+	// Value is the value that was passed to panic.
+	Value any
+
+	// Stack is the stack trace of the panicking goroutine at the time
+	// that the panic was recovered.
+	Stack []byte
+}
+
+func (err *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v\n\n%s", err.Value, err.Stack)
+}
+
+// Unwrap returns the panic value if it is an error, or nil otherwise.
+func (err *PanicError) Unwrap() error {
+	e, _ := err.Value.(error)
+	return e
+}
+
+// catch calls f, converting a panic into a *PanicError.
+func catch[T any](f func() (T, error)) (val T, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+
+	return f()
+}
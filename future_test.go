@@ -1,7 +1,11 @@
 package xsync_test
 
 import (
+	"context"
+	"errors"
+	"io"
 	"testing"
+	"time"
 
 	"deedles.dev/xsync"
 )
@@ -21,6 +25,60 @@ func TestFuture(t *testing.T) {
 	}
 }
 
+func TestFutureGetContext(t *testing.T) {
+	f, complete := xsync.NewFuture[int]()
+
+	_, ok := f.TryGet()
+	if ok {
+		t.Fatal("incomplete future returned a value")
+	}
+
+	ctx, cancel := context.WithCancelCause(t.Context())
+	cancel(io.EOF)
+	_, err := f.GetContext(ctx)
+	if err != io.EOF {
+		t.Fatalf("expected cause but got %v", err)
+	}
+
+	_, err = f.GetTimeout(time.Millisecond)
+	if err != context.DeadlineExceeded {
+		t.Fatalf("expected deadline exceeded but got %v", err)
+	}
+
+	complete(3)
+	val, ok := f.TryGet()
+	if !ok || val != 3 {
+		t.Fatal(val, ok)
+	}
+	val, err = f.GetContext(t.Context())
+	if err != nil || val != 3 {
+		t.Fatal(val, err)
+	}
+}
+
+func TestCancelFuture(t *testing.T) {
+	stopped := make(chan struct{})
+	f := xsync.GoContext(t.Context(), func(ctx context.Context) (int, error) {
+		defer close(stopped)
+		<-ctx.Done()
+		return 3, nil
+	})
+
+	r1 := f.Attach()
+	r2 := f.Attach()
+	r1()
+	r1()
+	if _, _, ok := f.TryGet(); ok {
+		t.Fatal("future canceled with consumers still attached")
+	}
+
+	r2()
+	<-stopped
+	if _, err := f.Get(); err != xsync.ErrCanceled {
+		t.Fatalf("expected ErrCanceled but got %v", err)
+	}
+}
+
 func BenchmarkFuture(b *testing.B) {
 	for b.Loop() {
 		f, complete := xsync.NewFuture[int]()
@@ -28,3 +86,36 @@ func BenchmarkFuture(b *testing.B) {
 		f.Get()
 	}
 }
+
+func TestErrFuture(t *testing.T) {
+	f, complete := xsync.NewErrFuture[int]()
+	go func() {
+		complete(0, io.EOF)
+		complete(3, nil)
+	}()
+
+	val, err := f.Get()
+	if err != io.EOF {
+		t.Fatal(err)
+	}
+	if val != 0 {
+		t.Fatal(val)
+	}
+}
+
+func TestGoErrPanic(t *testing.T) {
+	_, err := xsync.GoErr(func() (int, error) {
+		panic(io.ErrUnexpectedEOF)
+	}).Get()
+
+	var perr *xsync.PanicError
+	if !errors.As(err, &perr) {
+		t.Fatalf("expected *PanicError but got %T", err)
+	}
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("expected wrapped panic value but got %v", perr.Value)
+	}
+	if len(perr.Stack) == 0 {
+		t.Fatal("missing stack trace")
+	}
+}
@@ -0,0 +1,122 @@
+package xsync
+
+import "sync"
+
+// A Group deduplicates concurrent calls that share a key so that
+// only one of them is actually executed at a time, similar to
+// golang.org/x/sync/singleflight but with typed keys and values.
+//
+// A zero-value Group is ready to use. A Group must not be copied
+// after first use.
+type Group[K comparable, V any] struct {
+	_ noCopy
+
+	calls Map[K, *groupCall[V]]
+}
+
+type groupCall[V any] struct {
+	future   *ErrFuture[V]
+	complete func(V, error)
+
+	m      sync.Mutex
+	dups   int
+	sealed bool
+	shared bool
+}
+
+// GroupResult holds the results of a call made via [Group.DoChan].
+type GroupResult[V any] struct {
+	Val    V
+	Err    error
+	Shared bool
+}
+
+// Do calls fn and returns its results, making sure that only one
+// call for a given key is in-flight at a time. If a duplicate call
+// comes in, the duplicate caller waits for the original to complete
+// and receives the same results. The shared return indicates whether
+// the results were given to multiple callers.
+//
+// If fn panics, the panic is recovered and all callers receive a
+// [*PanicError] describing it.
+func (g *Group[K, V]) Do(key K, fn func() (V, error)) (v V, err error, shared bool) {
+	c, leader := g.join(key)
+	if leader {
+		g.call(key, c, fn)
+	}
+
+	v, err = c.future.Get()
+	return v, err, c.shared
+}
+
+// DoChan is like [Do] but returns a channel that will receive the
+// results when they are ready instead of blocking. The returned
+// channel is buffered and will not be closed.
+func (g *Group[K, V]) DoChan(key K, fn func() (V, error)) <-chan GroupResult[V] {
+	r := make(chan GroupResult[V], 1)
+
+	c, leader := g.join(key)
+	if leader {
+		go g.call(key, c, fn)
+	}
+
+	go func() {
+		v, err := c.future.Get()
+		r <- GroupResult[V]{Val: v, Err: err, Shared: c.shared}
+	}()
+
+	return r
+}
+
+// Forget tells the Group to forget about key. Future calls to Do for
+// that key will call the function rather than waiting for an earlier
+// call to complete.
+func (g *Group[K, V]) Forget(key K) {
+	g.calls.Delete(key)
+}
+
+// join returns the in-flight call for key, registering the caller as
+// a duplicate of it, or a new call that the caller is the leader of
+// if there is no such call. A call that has already finished but has
+// not yet been removed is never joined.
+func (g *Group[K, V]) join(key K) (c *groupCall[V], leader bool) {
+	for {
+		c, loaded := g.load(key)
+		if !loaded {
+			return c, true
+		}
+
+		c.m.Lock()
+		sealed := c.sealed
+		if !sealed {
+			c.dups++
+		}
+		c.m.Unlock()
+
+		if !sealed {
+			return c, false
+		}
+	}
+}
+
+func (g *Group[K, V]) load(key K) (c *groupCall[V], loaded bool) {
+	if c, ok := g.calls.Load(key); ok {
+		return c, true
+	}
+
+	c = new(groupCall[V])
+	c.future, c.complete = NewErrFuture[V]()
+	return g.calls.LoadOrStore(key, c)
+}
+
+func (g *Group[K, V]) call(key K, c *groupCall[V], fn func() (V, error)) {
+	v, err := catch(fn)
+	g.calls.CompareAndDelete(key, c)
+
+	c.m.Lock()
+	c.sealed = true
+	c.shared = c.dups > 0
+	c.m.Unlock()
+
+	c.complete(v, err)
+}
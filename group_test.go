@@ -0,0 +1,80 @@
+package xsync_test
+
+import (
+	"errors"
+	"io"
+	"sync/atomic"
+	"testing"
+
+	"deedles.dev/xsync"
+)
+
+func TestGroupDo(t *testing.T) {
+	var g xsync.Group[string, int]
+	v, err, shared := g.Do("key", func() (int, error) { return 3, nil })
+	if v != 3 || err != nil || shared {
+		t.Fatal(v, err, shared)
+	}
+
+	_, err, _ = g.Do("key", func() (int, error) { return 0, io.EOF })
+	if err != io.EOF {
+		t.Fatalf("expected EOF but got %v", err)
+	}
+}
+
+func TestGroupDoDupSuppress(t *testing.T) {
+	var g xsync.Group[string, int]
+	var calls atomic.Int32
+	release := make(chan struct{})
+	fn := func() (int, error) {
+		calls.Add(1)
+		<-release
+		return 3, nil
+	}
+
+	results := make([]<-chan xsync.GroupResult[int], 0, 10)
+	for range 10 {
+		results = append(results, g.DoChan("key", fn))
+	}
+
+	close(release)
+	for _, r := range results {
+		r := <-r
+		if r.Val != 3 || r.Err != nil || !r.Shared {
+			t.Fatal(r)
+		}
+	}
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("expected 1 call but got %v", n)
+	}
+}
+
+func TestGroupForget(t *testing.T) {
+	var g xsync.Group[string, int]
+	release := make(chan struct{})
+	first := g.DoChan("key", func() (int, error) {
+		<-release
+		return 1, nil
+	})
+
+	g.Forget("key")
+	v, _, _ := g.Do("key", func() (int, error) { return 2, nil })
+	if v != 2 {
+		t.Fatal(v)
+	}
+
+	close(release)
+	if r := <-first; r.Val != 1 {
+		t.Fatal(r.Val)
+	}
+}
+
+func TestGroupPanic(t *testing.T) {
+	var g xsync.Group[string, int]
+	_, err, _ := g.Do("key", func() (int, error) { panic("oops") })
+
+	var perr *xsync.PanicError
+	if !errors.As(err, &perr) || perr.Value != "oops" {
+		t.Fatalf("expected *PanicError but got %v", err)
+	}
+}
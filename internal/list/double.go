@@ -22,6 +22,31 @@ func (ls *Double[T]) Push(v T) {
 	ls.tail = &n
 }
 
+// PushFront adds a new node containing v to the head of the list.
+func (ls *Double[T]) PushFront(v T) {
+	n := DoubleNode[T]{Val: v, next: ls.head}
+	if ls.head == nil {
+		ls.head = &n
+		ls.tail = &n
+		return
+	}
+
+	ls.head.prev = &n
+	ls.head = &n
+}
+
+// Head returns the first node of the list, or nil if the list is
+// empty.
+func (ls *Double[T]) Head() *DoubleNode[T] {
+	return ls.head
+}
+
+// Tail returns the last node of the list, or nil if the list is
+// empty.
+func (ls *Double[T]) Tail() *DoubleNode[T] {
+	return ls.tail
+}
+
 // Remove removes the given node from the list.
 func (ls *Double[T]) Remove(n *DoubleNode[T]) {
 	if ls.head == ls.tail {
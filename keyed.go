@@ -0,0 +1,139 @@
+package xsync
+
+import (
+	"iter"
+
+	"deedles.dev/xsync/internal/list"
+)
+
+// A KeyedQueue is like a [Queue] but coalesces values that share a
+// key. If a value is pushed while another value with the same key is
+// still in the queue, the pending value is updated in place instead
+// of the new value being added to the back of the queue.
+//
+// A KeyedQueue must be created with [NewKeyedQueue]. The caveats
+// about garbage collection described for [Queue] also apply to
+// KeyedQueue.
+type KeyedQueue[K comparable, V any] struct {
+	q Queue[V]
+}
+
+// NewKeyedQueue returns a new KeyedQueue that uses key to determine
+// the key of each value. When a value is pushed with the same key as
+// a value already in the queue, the pending value is replaced with
+// the result of calling merge with the pending value and the new one.
+// If merge is nil, the pending value is simply replaced by the new
+// one. A merged value remains associated with the original key even
+// if calling key on it would return a different one.
+func NewKeyedQueue[K comparable, V any](key func(V) K, merge func(old, new V) V) *KeyedQueue[K, V] {
+	if merge == nil {
+		merge = func(old, new V) V { return new }
+	}
+
+	kq := new(KeyedQueue[K, V])
+	kq.q.newBuf = func() queueBuffer[V] {
+		return &keyedBuffer[K, V]{
+			key:   key,
+			merge: merge,
+			nodes: make(map[K]*list.DoubleNode[keyedVal[K, V]]),
+		}
+	}
+	return kq
+}
+
+func (kq *KeyedQueue[K, V]) Stop() {
+	kq.q.Stop()
+}
+
+// Push returns a channel that enqueues values sent to it, coalescing
+// them with pending values that have the same key. Closing this
+// channel will cause the channel returned by Pop to be closed once
+// the queue's contents are emptied, similar to how a regular channel
+// works.
+func (kq *KeyedQueue[K, V]) Push() chan<- V {
+	return kq.q.Push()
+}
+
+// Pop returns a channel that yields values from the queue when they
+// are available. The channel will be closed when the queue is
+// stopped.
+func (kq *KeyedQueue[K, V]) Pop() <-chan V {
+	return kq.q.Pop()
+}
+
+// All returns an iterator over all values currently in the queue.
+// Receiving from the channel will empty the queue. The channel will
+// block until there is at least one value in the queue.
+//
+// Like the channel returned by [Pop], it will be closed when the
+// queue is stopped.
+func (kq *KeyedQueue[K, V]) All() <-chan iter.Seq[V] {
+	return kq.q.All()
+}
+
+// Len returns the number of distinct keys currently in the queue.
+func (kq *KeyedQueue[K, V]) Len() int {
+	return kq.q.Len()
+}
+
+// keyedBuffer is a FIFO queueBuffer that coalesces values by key.
+type keyedBuffer[K comparable, V any] struct {
+	key   func(V) K
+	merge func(old, new V) V
+
+	ls    list.Double[keyedVal[K, V]]
+	nodes map[K]*list.DoubleNode[keyedVal[K, V]]
+}
+
+// keyedVal is a value in a keyedBuffer along with the key that it was
+// originally pushed with.
+type keyedVal[K comparable, V any] struct {
+	key K
+	val V
+}
+
+func (b *keyedBuffer[K, V]) len() int { return len(b.nodes) }
+
+func (b *keyedBuffer[K, V]) push(v V) {
+	k := b.key(v)
+	if n, ok := b.nodes[k]; ok {
+		n.Val.val = b.merge(n.Val.val, v)
+		return
+	}
+
+	b.ls.Push(keyedVal[K, V]{key: k, val: v})
+	b.nodes[k] = b.ls.Tail()
+}
+
+func (b *keyedBuffer[K, V]) peek() (v V) {
+	if n := b.ls.Head(); n != nil {
+		return n.Val.val
+	}
+	return v
+}
+
+func (b *keyedBuffer[K, V]) pop() {
+	n := b.ls.Head()
+	if n == nil {
+		return
+	}
+
+	b.ls.Remove(n)
+	delete(b.nodes, n.Val.key)
+}
+
+func (b *keyedBuffer[K, V]) all() iter.Seq[V] {
+	nodes := b.ls.Nodes()
+	return func(yield func(V) bool) {
+		for n := range nodes {
+			if !yield(n.Val.val) {
+				return
+			}
+		}
+	}
+}
+
+func (b *keyedBuffer[K, V]) clear() {
+	b.ls = list.Double[keyedVal[K, V]]{}
+	clear(b.nodes)
+}
@@ -0,0 +1,76 @@
+package xsync_test
+
+import (
+	"slices"
+	"testing"
+
+	"deedles.dev/xsync"
+)
+
+type refresh struct {
+	id    string
+	count int
+}
+
+func TestKeyedQueue(t *testing.T) {
+	kq := xsync.NewKeyedQueue(
+		func(r refresh) string { return r.id },
+		func(old, new refresh) refresh { return refresh{id: old.id, count: old.count + new.count} },
+	)
+	defer kq.Stop()
+
+	for _, id := range []string{"a", "b", "a", "c", "a"} {
+		kq.Push() <- refresh{id: id, count: 1}
+	}
+	if n := kq.Len(); n != 3 {
+		t.Fatal(n)
+	}
+
+	got := slices.Collect(<-kq.All())
+	want := []refresh{{"a", 3}, {"b", 1}, {"c", 1}}
+	if !slices.Equal(got, want) {
+		t.Fatal(got)
+	}
+
+	kq.Push() <- refresh{id: "a", count: 1}
+	if v := <-kq.Pop(); v != (refresh{"a", 1}) {
+		t.Fatal(v)
+	}
+}
+
+func TestKeyedQueueReplace(t *testing.T) {
+	kq := xsync.NewKeyedQueue(func(r refresh) string { return r.id }, nil)
+	defer kq.Stop()
+
+	kq.Push() <- refresh{id: "a", count: 1}
+	kq.Push() <- refresh{id: "b", count: 1}
+	kq.Push() <- refresh{id: "a", count: 2}
+	if v := <-kq.Pop(); v != (refresh{"a", 2}) {
+		t.Fatal(v)
+	}
+	if v := <-kq.Pop(); v != (refresh{"b", 1}) {
+		t.Fatal(v)
+	}
+}
+
+func TestKeyedQueueMergeChangesKey(t *testing.T) {
+	kq := xsync.NewKeyedQueue(
+		func(r refresh) string { return r.id },
+		func(old, new refresh) refresh { return refresh{id: "x", count: old.count + new.count} },
+	)
+	defer kq.Stop()
+
+	kq.Push() <- refresh{id: "a", count: 1}
+	kq.Push() <- refresh{id: "a", count: 1}
+	if v := <-kq.Pop(); v != (refresh{"x", 2}) {
+		t.Fatal(v)
+	}
+	if n := kq.Len(); n != 0 {
+		t.Fatal(n)
+	}
+
+	kq.Push() <- refresh{id: "a", count: 1}
+	if v := <-kq.Pop(); v != (refresh{"a", 1}) {
+		t.Fatal(v)
+	}
+}
@@ -0,0 +1,34 @@
+package xsync
+
+// A Memo caches the results of calling a function with a given key.
+// Concurrent requests for the same key share a single call of the
+// function.
+type Memo[K comparable, V any] struct {
+	f func(K) V
+	m Map[K, *Future[V]]
+}
+
+// NewMemo returns a new Memo that caches the results of f.
+func NewMemo[K comparable, V any](f func(K) V) *Memo[K, V] {
+	return &Memo[K, V]{f: f}
+}
+
+// Get returns a future that yields the result of calling the Memo's
+// function with key. If there is already a future for key, whether
+// completed or not, that future is returned. Otherwise, a new lazy
+// future is created that calls the function when it is first used.
+func (m *Memo[K, V]) Get(key K) *Future[V] {
+	if f, ok := m.m.Load(key); ok {
+		return f
+	}
+
+	f, _ := m.m.LoadOrStore(key, Lazy(func() V { return m.f(key) }))
+	return f
+}
+
+// Forget removes the future for key from the cache, if there is one,
+// so that the next call to Get for key calls the function again.
+// Futures that have already been returned by Get are unaffected.
+func (m *Memo[K, V]) Forget(key K) {
+	m.m.Delete(key)
+}
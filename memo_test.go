@@ -0,0 +1,57 @@
+package xsync_test
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"deedles.dev/xsync"
+)
+
+func TestLazy(t *testing.T) {
+	var started atomic.Bool
+	f := xsync.Lazy(func() int {
+		started.Store(true)
+		return 3
+	})
+	if started.Load() {
+		t.Fatal("lazy future started early")
+	}
+
+	if v := f.Get(); v != 3 {
+		t.Fatal(v)
+	}
+	if !started.Load() {
+		t.Fatal("lazy future never started")
+	}
+}
+
+func TestMemo(t *testing.T) {
+	var calls atomic.Int32
+	memo := xsync.NewMemo(func(k int) int {
+		calls.Add(1)
+		return k * 2
+	})
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v := memo.Get(3).Get(); v != 6 {
+				t.Error(v)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("expected 1 call but got %v", n)
+	}
+
+	memo.Forget(3)
+	memo.Get(3).Get()
+	if n := calls.Load(); n != 2 {
+		t.Fatalf("expected 2 calls but got %v", n)
+	}
+}
@@ -0,0 +1,667 @@
+package xsync
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"deedles.dev/xsync/internal/list"
+)
+
+// A Codec converts values to and from bytes for storage by a
+// [PersistentQueue].
+type Codec[T any] interface {
+	Marshal(v T) ([]byte, error)
+	Unmarshal(data []byte) (T, error)
+}
+
+// GobCodec is a [Codec] that uses [encoding/gob].
+type GobCodec[T any] struct{}
+
+func (GobCodec[T]) Marshal(v T) ([]byte, error) {
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(v)
+	return buf.Bytes(), err
+}
+
+func (GobCodec[T]) Unmarshal(data []byte) (v T, err error) {
+	err = gob.NewDecoder(bytes.NewReader(data)).Decode(&v)
+	return v, err
+}
+
+// JSONCodec is a [Codec] that uses [encoding/json].
+type JSONCodec[T any] struct{}
+
+func (JSONCodec[T]) Marshal(v T) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (JSONCodec[T]) Unmarshal(data []byte) (v T, err error) {
+	err = json.Unmarshal(data, &v)
+	return v, err
+}
+
+// SyncPolicy determines when a [PersistentQueue] flushes its log to
+// stable storage.
+type SyncPolicy int
+
+const (
+	// SyncAlways flushes the log after every push and
+	// acknowledgement, and flushes the queue's directory whenever a
+	// segment is created or removed.
+	SyncAlways SyncPolicy = iota
+
+	// SyncNever leaves flushing to the operating system, except when
+	// a segment is completed or the queue is closed. Data that has not
+	// been flushed may be lost if the system crashes.
+	SyncNever
+)
+
+// PersistOptions configures a [PersistentQueue].
+type PersistOptions struct {
+	// Sync determines when the log is flushed to stable storage.
+	Sync SyncPolicy
+
+	// SegmentSize is the size in bytes after which a new log segment
+	// is started. If it is zero or less, a default of 64 MiB is used.
+	SegmentSize int64
+}
+
+const defaultSegmentSize = 64 << 20
+
+// A PersistentQueue is a FIFO queue that records its contents in a
+// log on disk so that they survive restarts. It presents the same
+// channel-based interface as [Queue], along with an alternative
+// consumer channel that requires values to be explicitly
+// acknowledged.
+//
+// Values received from Pop are acknowledged automatically. Values
+// received from Deliveries remain in the log until they are
+// acknowledged, and unacknowledged values are delivered again the
+// next time the queue is opened.
+//
+// A PersistentQueue must be created with [OpenPersistentQueue] and
+// closed with Close. It is not stopped when garbage collected.
+type PersistentQueue[T any] struct {
+	stop func()
+
+	add     chan T
+	pop     chan T
+	deliver chan Delivery[T]
+	req     chan func(*persistRunner[T])
+	exit    chan struct{}
+
+	err error
+}
+
+// Delivery is a value received from a [PersistentQueue] that must be
+// acknowledged once it has been processed.
+type Delivery[T any] struct {
+	Value T
+
+	id uint64
+	q  *PersistentQueue[T]
+}
+
+// Ack acknowledges the delivery, permanently removing its value from
+// the queue. Acknowledging a delivery more than once has no further
+// effect. If the queue has been closed, it returns [ErrQueueClosed].
+func (d Delivery[T]) Ack() error {
+	err := ErrQueueClosed
+	d.q.do(func(r *persistRunner[T]) { err = r.ack(d.id) })
+	return err
+}
+
+// OpenPersistentQueue opens the queue stored in dir, creating it if
+// necessary, and recovers any values that were pushed but not
+// acknowledged when it was last used. Values are encoded using codec.
+// If opts is nil, default options are used.
+//
+// A directory must not be used by more than one PersistentQueue at a
+// time.
+func OpenPersistentQueue[T any](dir string, codec Codec[T], opts *PersistOptions) (*PersistentQueue[T], error) {
+	if opts == nil {
+		opts = new(PersistOptions)
+	}
+	segmentSize := opts.SegmentSize
+	if segmentSize <= 0 {
+		segmentSize = defaultSegmentSize
+	}
+
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return nil, err
+	}
+
+	runner := persistRunner[T]{
+		dir:         dir,
+		codec:       codec,
+		sync:        opts.Sync,
+		segmentSize: segmentSize,
+		segments:    make(map[uint64]*segment),
+		inflight:    make(map[uint64]uint64),
+	}
+	err = runner.recover()
+	if err != nil {
+		runner.closeFile()
+		return nil, err
+	}
+
+	q := PersistentQueue[T]{
+		add:     make(chan T),
+		pop:     make(chan T),
+		deliver: make(chan Delivery[T]),
+		req:     make(chan func(*persistRunner[T])),
+		exit:    make(chan struct{}),
+	}
+	runner.q = &q
+
+	done := make(chan struct{})
+	q.stop = sync.OnceFunc(func() { close(done) })
+	go runner.run(done)
+
+	return &q, nil
+}
+
+// Push returns a channel that enqueues values sent to it. A send
+// completes once the queue has received the value, and the value is
+// written to the log before the next one is accepted. Closing this
+// channel will cause the channels returned by Pop and Deliveries to
+// be closed once the queue's contents have all been delivered. The
+// queue itself keeps running until every delivery has also been
+// acknowledged or Close is called.
+//
+// A completed send does not mean that the value has been written to
+// the log, even with [SyncAlways]. If writing to the log fails, the
+// value is lost and the queue is closed. The error is only reported
+// afterwards by Err and Close. Callers that need to know that a value
+// is durable should use PushWait instead.
+func (q *PersistentQueue[T]) Push() chan<- T {
+	return q.add
+}
+
+// PushWait adds v to the queue and waits for it to be written to the
+// log, and flushed to stable storage if the queue's SyncPolicy is
+// [SyncAlways], before returning. If writing to the log fails, the
+// queue is closed and the error is returned. If the queue has already
+// been closed or its push channel has been closed, it returns
+// [ErrQueueClosed].
+func (q *PersistentQueue[T]) PushWait(v T) error {
+	err := ErrQueueClosed
+	q.do(func(r *persistRunner[T]) {
+		if r.add == nil {
+			return
+		}
+
+		err = r.push(v)
+		if err != nil {
+			r.q.err = err
+		}
+	})
+	return err
+}
+
+// Pop returns a channel that yields values from the queue when they
+// are available. Values are acknowledged as soon as they are
+// received. The channel will be closed when the queue is closed.
+func (q *PersistentQueue[T]) Pop() <-chan T {
+	return q.pop
+}
+
+// Deliveries is like Pop but yields values that must be acknowledged
+// via [Delivery.Ack]. Values are yielded by only one of the two
+// channels.
+func (q *PersistentQueue[T]) Deliveries() <-chan Delivery[T] {
+	return q.deliver
+}
+
+// Len returns the number of values that have not yet been delivered.
+func (q *PersistentQueue[T]) Len() (n int) {
+	q.do(func(r *persistRunner[T]) { n = r.n })
+	return n
+}
+
+// Close stops the queue and closes its log. Values that have not been
+// acknowledged remain in the log. It returns the first error that the
+// queue encountered, if any.
+func (q *PersistentQueue[T]) Close() error {
+	q.stop()
+	<-q.exit
+	return q.err
+}
+
+// Err returns the error that caused the queue to close, if any. It
+// returns nil while the queue is running.
+func (q *PersistentQueue[T]) Err() error {
+	select {
+	case <-q.exit:
+		return q.err
+	default:
+		return nil
+	}
+}
+
+// do runs f in the queue's runner goroutine and waits for it to
+// return. It returns false without running f if the runner has
+// exited.
+func (q *PersistentQueue[T]) do(f func(*persistRunner[T])) bool {
+	return runnerDo(q.req, q.exit, f)
+}
+
+const (
+	recordPush byte = iota + 1
+	recordAck
+)
+
+// recordHeaderSize is the size of a record's kind, ID, and payload
+// length. The payload is followed by a CRC-32 of the header and
+// payload.
+const recordHeaderSize = 1 + 8 + 4
+
+type segment struct {
+	seq  uint64
+	live int
+}
+
+type persistItem[T any] struct {
+	id  uint64
+	seg uint64
+	val T
+}
+
+type persistRunner[T any] struct {
+	q   *PersistentQueue[T]
+	add chan T
+
+	dir         string
+	codec       Codec[T]
+	sync        SyncPolicy
+	segmentSize int64
+
+	file     *os.File
+	size     int64
+	segments map[uint64]*segment
+	order    []uint64
+	nextID   uint64
+
+	pending  list.Single[persistItem[T]]
+	n        int
+	inflight map[uint64]uint64
+}
+
+func (r *persistRunner[T]) run(done <-chan struct{}) {
+	q := r.q
+	r.add = q.add
+
+	// Once the push channel has been closed and everything has been
+	// delivered, the output channels are closed but the runner keeps
+	// going so that outstanding deliveries can still be acknowledged.
+	outputs := true
+	finish := func() bool {
+		if r.add != nil || r.n > 0 {
+			return false
+		}
+		if outputs {
+			close(q.pop)
+			close(q.deliver)
+			outputs = false
+		}
+		return len(r.inflight) == 0
+	}
+
+	defer func() {
+		if outputs {
+			close(q.pop)
+			close(q.deliver)
+		}
+		if r.add != nil {
+			// Ensure that future attempts to send to the queue will fail.
+			close(r.add)
+		}
+		if err := r.closeFile(); err != nil && q.err == nil {
+			q.err = err
+		}
+		close(q.exit)
+	}()
+
+	for {
+		var pop chan T
+		var deliver chan Delivery[T]
+		next := r.pending.Peek()
+		if r.n > 0 {
+			pop = q.pop
+			deliver = q.deliver
+		}
+
+		select {
+		case <-done:
+			return
+
+		case f := <-q.req:
+			if !runnerServe(done, r, f) {
+				return
+			}
+			if q.err != nil || finish() {
+				return
+			}
+
+		case v, ok := <-r.add:
+			if !ok {
+				r.add = nil
+				if finish() {
+					return
+				}
+				continue
+			}
+
+			err := r.push(v)
+			if err != nil {
+				q.err = err
+				return
+			}
+
+		case pop <- next.val:
+			r.shift()
+			r.inflight[next.id] = next.seg
+			err := r.ack(next.id)
+			if err != nil {
+				q.err = err
+				return
+			}
+			if finish() {
+				return
+			}
+
+		case deliver <- Delivery[T]{Value: next.val, id: next.id, q: q}:
+			r.shift()
+			r.inflight[next.id] = next.seg
+			if finish() {
+				return
+			}
+		}
+	}
+}
+
+func (r *persistRunner[T]) shift() {
+	r.pending.Pop()
+	r.n--
+}
+
+func (r *persistRunner[T]) push(v T) error {
+	data, err := r.codec.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("marshal: %w", err)
+	}
+
+	id := r.nextID
+	r.nextID++
+	err = r.write(recordPush, id, data)
+	if err != nil {
+		return err
+	}
+
+	seg := r.order[len(r.order)-1]
+	r.segments[seg].live++
+	r.pending.Enqueue(persistItem[T]{id: id, seg: seg, val: v})
+	r.n++
+	return nil
+}
+
+func (r *persistRunner[T]) ack(id uint64) error {
+	seg, ok := r.inflight[id]
+	if !ok {
+		return nil
+	}
+
+	// Only forget the value once the acknowledgement has been recorded
+	// so that a failed Ack can be retried.
+	err := r.write(recordAck, id, nil)
+	if err != nil {
+		return err
+	}
+
+	delete(r.inflight, id)
+	r.segments[seg].live--
+	return r.compact()
+}
+
+// compact removes segments from the front of the log that no longer
+// contain any unacknowledged values. Segments are only ever removed
+// in order so that acknowledgements of values in earlier segments are
+// never lost.
+func (r *persistRunner[T]) compact() error {
+	for len(r.order) > 1 {
+		seq := r.order[0]
+		if r.segments[seq].live > 0 {
+			return nil
+		}
+
+		err := os.Remove(r.segmentPath(seq))
+		if err != nil {
+			return err
+		}
+		delete(r.segments, seq)
+		r.order = r.order[1:]
+
+		err = r.syncDir()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *persistRunner[T]) write(kind byte, id uint64, payload []byte) error {
+	if r.size >= r.segmentSize {
+		err := r.rotate()
+		if err != nil {
+			return err
+		}
+	}
+
+	buf := make([]byte, recordHeaderSize, recordHeaderSize+len(payload)+4)
+	buf[0] = kind
+	binary.BigEndian.PutUint64(buf[1:], id)
+	binary.BigEndian.PutUint32(buf[9:], uint32(len(payload)))
+	buf = append(buf, payload...)
+	buf = binary.BigEndian.AppendUint32(buf, crc32.ChecksumIEEE(buf))
+
+	n, err := r.file.Write(buf)
+	r.size += int64(n)
+	if err != nil {
+		return err
+	}
+
+	if r.sync == SyncAlways {
+		return r.file.Sync()
+	}
+	return nil
+}
+
+// rotate finishes the current segment, if any, and starts a new one.
+func (r *persistRunner[T]) rotate() error {
+	err := r.closeFile()
+	if err != nil {
+		return err
+	}
+
+	var seq uint64
+	if len(r.order) > 0 {
+		seq = r.order[len(r.order)-1] + 1
+	}
+
+	file, err := os.OpenFile(r.segmentPath(seq), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+
+	r.file = file
+	r.size = 0
+	r.segments[seq] = &segment{seq: seq}
+	r.order = append(r.order, seq)
+
+	err = r.syncDir()
+	if err != nil {
+		return err
+	}
+	return r.compact()
+}
+
+// syncDir flushes the queue's directory to stable storage if the
+// sync policy is SyncAlways so that the creation and removal of
+// segments survive a crash.
+func (r *persistRunner[T]) syncDir() error {
+	if r.sync != SyncAlways {
+		return nil
+	}
+
+	dir, err := os.Open(r.dir)
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+	return dir.Sync()
+}
+
+func (r *persistRunner[T]) closeFile() error {
+	if r.file == nil {
+		return nil
+	}
+
+	file := r.file
+	r.file = nil
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func (r *persistRunner[T]) segmentPath(seq uint64) string {
+	return filepath.Join(r.dir, fmt.Sprintf("%016x.log", seq))
+}
+
+// recover reads the existing segments in the queue's directory,
+// rebuilding the list of unacknowledged values, and then starts a new
+// segment for further writes.
+func (r *persistRunner[T]) recover() error {
+	entries, err := os.ReadDir(r.dir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".log")
+		if !ok || entry.IsDir() {
+			continue
+		}
+		seq, err := strconv.ParseUint(name, 16, 64)
+		if err != nil {
+			continue
+		}
+
+		r.segments[seq] = &segment{seq: seq}
+		r.order = append(r.order, seq)
+	}
+	slices.Sort(r.order)
+
+	type recovered struct {
+		seg  uint64
+		data []byte
+	}
+	items := make(map[uint64]recovered)
+	for _, seq := range r.order {
+		err := r.readSegment(seq, func(kind byte, id uint64, payload []byte) {
+			r.nextID = max(r.nextID, id+1)
+			switch kind {
+			case recordPush:
+				items[id] = recovered{seg: seq, data: payload}
+			case recordAck:
+				delete(items, id)
+			}
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	ids := slices.Sorted(maps.Keys(items))
+	for _, id := range ids {
+		item := items[id]
+		v, err := r.codec.Unmarshal(item.data)
+		if err != nil {
+			return fmt.Errorf("unmarshal value %v: %w", id, err)
+		}
+
+		r.segments[item.seg].live++
+		r.pending.Enqueue(persistItem[T]{id: id, seg: item.seg, val: v})
+		r.n++
+	}
+
+	return r.rotate()
+}
+
+// readSegment calls f for each intact record in the segment. Reading
+// stops at the first incomplete or corrupted record, which can be
+// left behind by a crash in the middle of a write.
+func (r *persistRunner[T]) readSegment(seq uint64, f func(kind byte, id uint64, payload []byte)) error {
+	file, err := os.Open(r.segmentPath(seq))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	br := bufio.NewReader(file)
+	header := make([]byte, recordHeaderSize)
+	for {
+		_, err := io.ReadFull(br, header)
+		if err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return nil
+			}
+			return err
+		}
+
+		kind := header[0]
+		id := binary.BigEndian.Uint64(header[1:])
+		size := binary.BigEndian.Uint32(header[9:])
+		if (kind != recordPush && kind != recordAck) || int64(size) > info.Size() {
+			return nil
+		}
+
+		rest := make([]byte, int(size)+4)
+		_, err = io.ReadFull(br, rest)
+		if err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return nil
+			}
+			return err
+		}
+
+		payload, sum := rest[:size], binary.BigEndian.Uint32(rest[size:])
+		crc := crc32.Update(crc32.ChecksumIEEE(header), crc32.IEEETable, payload)
+		if crc != sum {
+			return nil
+		}
+
+		f(kind, id, payload)
+	}
+}
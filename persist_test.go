@@ -0,0 +1,186 @@
+package xsync_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"deedles.dev/xsync"
+)
+
+func TestPersistentQueue(t *testing.T) {
+	dir := t.TempDir()
+
+	q, err := xsync.OpenPersistentQueue(dir, xsync.JSONCodec[string]{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range []string{"a", "b", "c", "d"} {
+		q.Push() <- v
+	}
+
+	if v := <-q.Pop(); v != "a" {
+		t.Fatal(v)
+	}
+	d := <-q.Deliveries()
+	if d.Value != "b" {
+		t.Fatal(d.Value)
+	}
+	if err := d.Ack(); err != nil {
+		t.Fatal(err)
+	}
+	if d := <-q.Deliveries(); d.Value != "c" {
+		t.Fatal(d.Value)
+	}
+	if err := q.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Ack(); err != xsync.ErrQueueClosed {
+		t.Fatalf("expected ErrQueueClosed but got %v", err)
+	}
+
+	q, err = xsync.OpenPersistentQueue(dir, xsync.JSONCodec[string]{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.Close()
+
+	if n := q.Len(); n != 2 {
+		t.Fatal(n)
+	}
+	if v := <-q.Pop(); v != "c" {
+		t.Fatal(v)
+	}
+	if v := <-q.Pop(); v != "d" {
+		t.Fatal(v)
+	}
+}
+
+func TestPersistentQueueClosedPush(t *testing.T) {
+	dir := t.TempDir()
+
+	q, err := xsync.OpenPersistentQueue(dir, xsync.JSONCodec[string]{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.Push() <- "a"
+	close(q.Push())
+
+	d := <-q.Deliveries()
+	if _, ok := <-q.Deliveries(); ok {
+		t.Fatal("deliveries channel not closed")
+	}
+	if err := d.Ack(); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	q, err = xsync.OpenPersistentQueue(dir, xsync.JSONCodec[string]{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.Close()
+
+	if n := q.Len(); n != 0 {
+		t.Fatal(n)
+	}
+}
+
+func TestPersistentQueuePushWait(t *testing.T) {
+	dir := t.TempDir()
+
+	q, err := xsync.OpenPersistentQueue(dir, xsync.JSONCodec[string]{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := q.PushWait("a"); err != nil {
+		t.Fatal(err)
+	}
+	close(q.Push())
+	if err := q.PushWait("b"); err != xsync.ErrQueueClosed {
+		t.Fatalf("expected ErrQueueClosed but got %v", err)
+	}
+	if err := q.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.PushWait("c"); err != xsync.ErrQueueClosed {
+		t.Fatalf("expected ErrQueueClosed but got %v", err)
+	}
+
+	q, err = xsync.OpenPersistentQueue(dir, xsync.JSONCodec[string]{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.Close()
+
+	if v := <-q.Pop(); v != "a" {
+		t.Fatal(v)
+	}
+}
+
+func TestPersistentQueueCompaction(t *testing.T) {
+	dir := t.TempDir()
+	opts := xsync.PersistOptions{Sync: xsync.SyncNever, SegmentSize: 64}
+
+	q, err := xsync.OpenPersistentQueue(dir, xsync.GobCodec[int]{}, &opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range 100 {
+		q.Push() <- i
+	}
+	for i := range 100 {
+		if v := <-q.Pop(); v != i {
+			t.Fatal(v)
+		}
+	}
+	if err := q.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	segments, _ := filepath.Glob(filepath.Join(dir, "*.log"))
+	if len(segments) > 2 {
+		t.Fatalf("expected acknowledged segments to be removed but found %v", len(segments))
+	}
+}
+
+func TestPersistentQueueTornWrite(t *testing.T) {
+	dir := t.TempDir()
+
+	q, err := xsync.OpenPersistentQueue(dir, xsync.JSONCodec[int]{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.Push() <- 1
+	q.Push() <- 2
+	if err := q.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Simulate a crash in the middle of writing a record.
+	segments, _ := filepath.Glob(filepath.Join(dir, "*.log"))
+	last := segments[len(segments)-1]
+	data, err := os.ReadFile(last)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(last, data[:len(data)-3], 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	q, err = xsync.OpenPersistentQueue(dir, xsync.JSONCodec[int]{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.Close()
+
+	if n := q.Len(); n != 1 {
+		t.Fatal(n)
+	}
+	if v := <-q.Pop(); v != 1 {
+		t.Fatal(v)
+	}
+}
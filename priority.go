@@ -0,0 +1,225 @@
+package xsync
+
+import (
+	"container/heap"
+	"iter"
+	"slices"
+)
+
+// A PriorityQueue is like a [Queue] but yields values in priority
+// order, as determined by a user-supplied function, rather than FIFO
+// order. Values of equal priority are yielded in the order that they
+// were pushed.
+//
+// A PriorityQueue must be created with [NewPriorityQueue]. The
+// caveats about garbage collection described for [Queue] also apply
+// to PriorityQueue.
+type PriorityQueue[T any] struct {
+	q Queue[T]
+}
+
+// NewPriorityQueue returns a new PriorityQueue that yields values in
+// the order determined by less, which should return true if a has a
+// higher priority than b.
+func NewPriorityQueue[T any](less func(a, b T) bool) *PriorityQueue[T] {
+	pq := new(PriorityQueue[T])
+	pq.q.newBuf = func() queueBuffer[T] { return &heapBuffer[T]{less: less} }
+	return pq
+}
+
+func (pq *PriorityQueue[T]) Stop() {
+	pq.q.Stop()
+}
+
+// Push returns a channel that enqueues values sent to it. Closing
+// this channel will cause the channel returned by Pop to be closed
+// once the queue's contents are emptied, similar to how a regular
+// channel works.
+func (pq *PriorityQueue[T]) Push() chan<- T {
+	return pq.q.Push()
+}
+
+// Pop returns a channel that yields the highest priority value in the
+// queue when one is available. The channel will be closed when the
+// queue is stopped.
+func (pq *PriorityQueue[T]) Pop() <-chan T {
+	return pq.q.Pop()
+}
+
+// All returns an iterator over all values currently in the queue in
+// priority order. Receiving from the channel will empty the queue.
+// The channel will block until there is at least one value in the
+// queue.
+//
+// Like the channel returned by [Pop], it will be closed when the
+// queue is stopped.
+func (pq *PriorityQueue[T]) All() <-chan iter.Seq[T] {
+	return pq.q.All()
+}
+
+// PushItem adds v to the queue and returns a handle that can be used
+// to change its priority or remove it while it is still in the
+// queue. If the queue has been stopped or closed, it returns
+// [ErrQueueClosed].
+func (pq *PriorityQueue[T]) PushItem(v T) (item *PriorityItem[T], err error) {
+	err = ErrQueueClosed
+	pq.q.do(func(r *queueRunner[T]) {
+		if r.add == nil {
+			return
+		}
+
+		item = r.buf.(*heapBuffer[T]).pushItem(v)
+		err = nil
+	})
+	return item, err
+}
+
+// Update replaces the value of item with v and moves it to its new
+// position in the queue. It returns false if item is no longer in the
+// queue.
+func (pq *PriorityQueue[T]) Update(item *PriorityItem[T], v T) (ok bool) {
+	pq.q.do(func(r *queueRunner[T]) {
+		if item.index < 0 {
+			return
+		}
+
+		item.val = v
+		heap.Fix(r.buf.(*heapBuffer[T]), item.index)
+		ok = true
+	})
+	return ok
+}
+
+// Fix moves item to its correct position in the queue after its
+// priority has changed without its value being replaced, such as when
+// T is a pointer type and the value that it points to was modified.
+// It returns false if item is no longer in the queue.
+func (pq *PriorityQueue[T]) Fix(item *PriorityItem[T]) (ok bool) {
+	pq.q.do(func(r *queueRunner[T]) {
+		if item.index < 0 {
+			return
+		}
+
+		heap.Fix(r.buf.(*heapBuffer[T]), item.index)
+		ok = true
+	})
+	return ok
+}
+
+// Remove removes item from the queue. It returns false if item is no
+// longer in the queue.
+func (pq *PriorityQueue[T]) Remove(item *PriorityItem[T]) (ok bool) {
+	pq.q.do(func(r *queueRunner[T]) {
+		if item.index < 0 {
+			return
+		}
+
+		heap.Remove(r.buf.(*heapBuffer[T]), item.index)
+		ok = true
+	})
+	return ok
+}
+
+// PriorityItem is a handle to a value in a [PriorityQueue].
+type PriorityItem[T any] struct {
+	val   T
+	seq   uint64
+	index int
+}
+
+// heapBuffer is a queueBuffer that yields values in priority order.
+// It implements heap.Interface.
+type heapBuffer[T any] struct {
+	less  func(a, b T) bool
+	items []*PriorityItem[T]
+	seq   uint64
+}
+
+func (b *heapBuffer[T]) Len() int { return len(b.items) }
+
+func (b *heapBuffer[T]) Less(i, j int) bool {
+	return b.compare(b.items[i], b.items[j]) < 0
+}
+
+func (b *heapBuffer[T]) Swap(i, j int) {
+	b.items[i], b.items[j] = b.items[j], b.items[i]
+	b.items[i].index = i
+	b.items[j].index = j
+}
+
+func (b *heapBuffer[T]) Push(x any) {
+	item := x.(*PriorityItem[T])
+	item.index = len(b.items)
+	b.items = append(b.items, item)
+}
+
+func (b *heapBuffer[T]) Pop() any {
+	last := len(b.items) - 1
+	item := b.items[last]
+	b.items[last] = nil
+	b.items = b.items[:last]
+	item.index = -1
+	return item
+}
+
+// compare orders items by priority, falling back to insertion order
+// so that items of equal priority are stable.
+func (b *heapBuffer[T]) compare(i1, i2 *PriorityItem[T]) int {
+	switch {
+	case b.less(i1.val, i2.val):
+		return -1
+	case b.less(i2.val, i1.val):
+		return 1
+	case i1.seq < i2.seq:
+		return -1
+	case i1.seq > i2.seq:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func (b *heapBuffer[T]) pushItem(v T) *PriorityItem[T] {
+	item := &PriorityItem[T]{val: v, seq: b.seq}
+	b.seq++
+	heap.Push(b, item)
+	return item
+}
+
+func (b *heapBuffer[T]) len() int { return len(b.items) }
+
+func (b *heapBuffer[T]) push(v T) { b.pushItem(v) }
+
+func (b *heapBuffer[T]) peek() (v T) {
+	if len(b.items) == 0 {
+		return v
+	}
+	return b.items[0].val
+}
+
+func (b *heapBuffer[T]) pop() {
+	if len(b.items) > 0 {
+		heap.Pop(b)
+	}
+}
+
+func (b *heapBuffer[T]) all() iter.Seq[T] {
+	// The runner calls all on every pass of its loop, so defer sorting
+	// until the iterator is actually used. clear replaces items rather
+	// than modifying it, so the captured slice remains valid.
+	items := b.items
+	return func(yield func(T) bool) {
+		for _, item := range slices.SortedFunc(slices.Values(items), b.compare) {
+			if !yield(item.val) {
+				return
+			}
+		}
+	}
+}
+
+func (b *heapBuffer[T]) clear() {
+	for _, item := range b.items {
+		item.index = -1
+	}
+	b.items = nil
+}
@@ -0,0 +1,76 @@
+package xsync_test
+
+import (
+	"cmp"
+	"slices"
+	"testing"
+
+	"deedles.dev/xsync"
+)
+
+type job struct {
+	name     string
+	priority int
+}
+
+func jobLess(a, b job) bool {
+	return cmp.Less(a.priority, b.priority)
+}
+
+func TestPriorityQueue(t *testing.T) {
+	pq := xsync.NewPriorityQueue(jobLess)
+	defer pq.Stop()
+
+	for _, j := range []job{{"a", 2}, {"b", 1}, {"c", 2}, {"d", 0}, {"e", 1}} {
+		pq.Push() <- j
+	}
+
+	var got []string
+	for range 5 {
+		got = append(got, (<-pq.Pop()).name)
+	}
+	if !slices.Equal(got, []string{"d", "b", "e", "a", "c"}) {
+		t.Fatal(got)
+	}
+}
+
+func TestPriorityQueueItem(t *testing.T) {
+	pq := xsync.NewPriorityQueue(jobLess)
+	defer pq.Stop()
+
+	a, _ := pq.PushItem(job{"a", 1})
+	b, _ := pq.PushItem(job{"b", 2})
+	c, _ := pq.PushItem(job{"c", 3})
+
+	if !pq.Update(c, job{"c", 0}) {
+		t.Fatal("update failed")
+	}
+	if !pq.Remove(a) {
+		t.Fatal("remove failed")
+	}
+
+	var got []string
+	for j := range <-pq.All() {
+		got = append(got, j.name)
+	}
+	if !slices.Equal(got, []string{"c", "b"}) {
+		t.Fatal(got)
+	}
+	if pq.Update(b, job{"b", 0}) || pq.Remove(a) {
+		t.Fatal("modified item no longer in queue")
+	}
+}
+
+func BenchmarkPriorityQueue(b *testing.B) {
+	pq := xsync.NewPriorityQueue(jobLess)
+	defer pq.Stop()
+
+	for i := range 10000 {
+		pq.Push() <- job{priority: i}
+	}
+
+	for b.Loop() {
+		pq.Push() <- job{priority: -1}
+		<-pq.Pop()
+	}
+}
@@ -1,21 +1,54 @@
 package xsync
 
 import (
+	"context"
+	"errors"
 	"iter"
 	"runtime"
+	"slices"
 	"sync"
+	"time"
 
 	"deedles.dev/xsync/internal/list"
 )
 
+var (
+	// ErrQueueFull is returned when a value can not be added to a
+	// bounded queue because it is full.
+	ErrQueueFull = errors.New("queue full")
+
+	// ErrQueueClosed is returned when a value can not be added to a
+	// queue because it has been stopped or closed.
+	ErrQueueClosed = errors.New("queue closed")
+)
+
+// Overflow determines how a bounded [Queue] behaves when a value is
+// pushed to it while it is full.
+type Overflow int
+
+const (
+	// OverflowBlock causes pushes to block until there is room in the
+	// Queue.
+	OverflowBlock Overflow = iota
+
+	// OverflowDropNewest causes the value being pushed to be
+	// discarded.
+	OverflowDropNewest
+
+	// OverflowDropOldest causes the value at the front of the Queue to
+	// be discarded to make room for the value being pushed.
+	OverflowDropOldest
+)
+
 // A Queue concurrently collects values and returns them in FIFO
 // order. A zero value Queue is ready to use.
 //
-// A Queue is stopped when it is garbage collected. Therefore, a
-// reference to the Queue must be kept alive during its use or its
-// behavior will become undefined. Because of that, it is recommended
-// to access the Queue's channels via the methods every time instead
-// of storing a copy somewhere.
+// A Queue should be shut down explicitly with either Close or Stop.
+// As a backup, a Queue is also stopped when it is garbage collected,
+// discarding its contents. Therefore, a reference to the Queue must
+// be kept alive during its use or its behavior will become undefined.
+// Because of that, it is recommended to access the Queue's channels
+// via the methods every time instead of storing a copy somewhere.
 //
 // A Queue is initialized by calling any of its methods, so a copy of
 // a Queue made before those methods are called is a completely
@@ -29,27 +62,58 @@ import (
 // of this, such a Queue will need to be manually stopped with a call
 // to Queue.Stop.
 type Queue[T any] struct {
-	start sync.Once
-	stop  func()
+	// Cap is the maximum number of values that the Queue will hold at
+	// once. If it is zero or less, the Queue is unbounded. It must not
+	// be modified after the Queue has been initialized.
+	Cap int
+
+	// Overflow determines what happens when a value is pushed while
+	// the Queue is full. It has no effect on an unbounded Queue and
+	// must not be modified after the Queue has been initialized.
+	Overflow Overflow
 
-	add chan T
-	get chan T
-	all chan iter.Seq[T]
+	start  sync.Once
+	stop   func()
+	newBuf func() queueBuffer[T]
+
+	add  chan T
+	wait chan T
+	get  chan T
+	all  chan iter.Seq[T]
+	req  chan func(*queueRunner[T])
+	exit chan struct{}
+	left *[]T
 }
 
 func (q *Queue[T]) init() {
 	q.start.Do(func() {
 		q.add = make(chan T)
+		q.wait = make(chan T)
 		q.get = make(chan T)
 		q.all = make(chan iter.Seq[T])
+		q.req = make(chan func(*queueRunner[T]))
+		q.exit = make(chan struct{})
+		q.left = new([]T)
 
 		done := make(chan struct{})
 		q.stop = sync.OnceFunc(func() { close(done) })
 
+		newBuf := q.newBuf
+		if newBuf == nil {
+			newBuf = func() queueBuffer[T] { return new(listBuffer[T]) }
+		}
+
 		runner := queueRunner[T]{
-			add: q.add,
-			get: q.get,
-			all: q.all,
+			buf:      newBuf(),
+			add:      q.add,
+			wait:     q.wait,
+			get:      q.get,
+			all:      q.all,
+			req:      q.req,
+			exit:     q.exit,
+			left:     q.left,
+			cap:      q.Cap,
+			overflow: q.Overflow,
 		}
 		go runner.run(done)
 
@@ -57,20 +121,107 @@ func (q *Queue[T]) init() {
 	})
 }
 
+// Stop stops the Queue immediately. Values that are still in the
+// Queue are not delivered but can be retrieved with Undelivered once
+// the channel returned by Done is closed. To stop the Queue only once
+// its contents have been delivered, use Close instead. It is safe to
+// call Stop more than once.
 func (q *Queue[T]) Stop() {
 	q.init()
 	q.stop()
 }
 
+// Close stops the Queue from accepting new values, after which the
+// Queue will stop once its remaining contents have been delivered.
+// Sends that are already blocked when Close is called, such as those
+// waiting on a full Queue with [OverflowBlock], are accepted, ignoring
+// Cap if necessary, so that they are not lost.
+//
+// Sends made after Close are not accepted. A send to the channel
+// returned by Push blocks until the Queue stops and then panics, as
+// the channel is closed at that point, while PushContext and TryPush
+// return [ErrQueueClosed]. It is safe to call Close more than once and
+// after the channel returned by Push has been closed manually.
+func (q *Queue[T]) Close() {
+	q.do(func(r *queueRunner[T]) { r.close() })
+}
+
+// Done returns a channel that is closed once the Queue has stopped,
+// either due to a call to Stop or due to it being closed and then
+// emptied. After it is closed, the channels returned by Pop and All
+// are closed and no further values can be pushed.
+func (q *Queue[T]) Done() <-chan struct{} {
+	q.init()
+	return q.exit
+}
+
+// Undelivered returns the values that were still in the Queue when
+// it stopped. It returns nil if the Queue has not stopped yet, so it
+// should generally be called after the channel returned by Done is
+// closed.
+func (q *Queue[T]) Undelivered() []T {
+	q.init()
+
+	select {
+	case <-q.exit:
+		return *q.left
+	default:
+		return nil
+	}
+}
+
 // Push returns a channel that enqueues values sent to it. Closing
 // this channel will cause the channel returned by Pop to be closed
 // once the Queue's contents are emptied, similar to how a regular
 // channel works.
+//
+// If the Queue is bounded and full, the behavior of a send to the
+// channel depends on the Queue's Overflow policy. The channel is
+// closed when the Queue stops, so a send that is blocked on a full
+// Queue with [OverflowBlock] at that point will panic. Pushers that
+// may block across a call to Stop should use PushContext instead.
 func (q *Queue[T]) Push() chan<- T {
 	q.init()
 	return q.add
 }
 
+// PushContext adds v to the queue, blocking if necessary in the same
+// way as a send to the channel returned by Push. Unlike such a send,
+// it returns [ErrQueueClosed] instead of panicking if the Queue stops
+// first. If ctx is canceled first, it returns the context's cause.
+func (q *Queue[T]) PushContext(ctx context.Context, v T) error {
+	q.init()
+
+	select {
+	case q.wait <- v:
+		return nil
+	case <-q.exit:
+		return ErrQueueClosed
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	}
+}
+
+// TryPush attempts to add v to the queue without blocking. If the
+// Queue is full and its Overflow policy is [OverflowBlock] or
+// [OverflowDropNewest], it returns [ErrQueueFull] and v is not added.
+// If the Queue has been stopped or closed, or its push channel has
+// been closed, it returns [ErrQueueClosed].
+func (q *Queue[T]) TryPush(v T) error {
+	err := ErrQueueClosed
+	q.do(func(r *queueRunner[T]) {
+		switch {
+		case r.closing:
+			err = ErrQueueClosed
+		case !r.push(v):
+			err = ErrQueueFull
+		default:
+			err = nil
+		}
+	})
+	return err
+}
+
 // Pop returns a channel that yields values from the queue when they
 // are available. The channel will be closed when the Queue is
 // stopped.
@@ -90,56 +241,335 @@ func (q *Queue[T]) All() <-chan iter.Seq[T] {
 	return q.all
 }
 
+// Batches returns a channel that yields values from the queue in
+// batches. A batch is sent once it holds size values or once maxWait
+// has elapsed since its first value was taken from the queue,
+// whichever happens first. If size is zero or less, batches are only
+// limited by time. If maxWait is zero or less, batches are only
+// limited by size. It panics if both are zero or less.
+//
+// Values are taken from the queue as soon as they are available
+// except while a completed batch is waiting to be received. Time that
+// a value spends in the queue before it is taken does not count
+// towards maxWait, so a slow consumer of the returned channel can
+// cause values to wait for longer than maxWait in total.
+//
+// Every call to Batches starts a new consumer of the queue that
+// competes with any others for values, so it should generally only be
+// called once. The returned channel is closed after any final batch
+// has been sent once the channel returned by [Pop] is closed.
+func (q *Queue[T]) Batches(size int, maxWait time.Duration) <-chan []T {
+	if size <= 0 && maxWait <= 0 {
+		panic("batches must be limited by size or time")
+	}
+
+	out := make(chan []T)
+	go batch(q.Pop(), out, size, maxWait)
+	return out
+}
+
+func batch[T any](in <-chan T, out chan<- []T, size int, maxWait time.Duration) {
+	defer close(out)
+
+	timer := time.NewTimer(maxWait)
+	timer.Stop()
+	defer timer.Stop()
+
+	var vals []T
+	var wait <-chan time.Time
+	for {
+		select {
+		case v, ok := <-in:
+			if !ok {
+				if len(vals) > 0 {
+					out <- vals
+				}
+				return
+			}
+
+			vals = append(vals, v)
+			if len(vals) == 1 && maxWait > 0 {
+				timer.Reset(maxWait)
+				wait = timer.C
+			}
+			if size <= 0 || len(vals) < size {
+				continue
+			}
+
+		case <-wait:
+		}
+
+		timer.Stop()
+		wait = nil
+
+		out <- vals
+		vals = nil
+	}
+}
+
+// Len returns the number of values currently in the queue.
+func (q *Queue[T]) Len() (n int) {
+	q.do(func(r *queueRunner[T]) { n = r.buf.len() })
+	return n
+}
+
+// Peek returns the value at the front of the queue without removing
+// it. If the queue is empty, it returns false as the second return.
+func (q *Queue[T]) Peek() (v T, ok bool) {
+	q.do(func(r *queueRunner[T]) { v, ok = r.buf.peek(), r.buf.len() > 0 })
+	return v, ok
+}
+
+// Drain removes all values currently in the queue and returns them.
+// Unlike receiving from the channel returned by [All], it does not
+// block if the queue is empty.
+func (q *Queue[T]) Drain() (vals []T) {
+	q.do(func(r *queueRunner[T]) {
+		vals = make([]T, 0, r.buf.len())
+		for v := range r.buf.all() {
+			vals = append(vals, v)
+		}
+		r.clear()
+	})
+	return vals
+}
+
+// QueueStats holds statistics about a [Queue].
+type QueueStats struct {
+	// Pushed is the total number of values that have been added to
+	// the queue.
+	Pushed uint64
+
+	// Popped is the total number of values that have been removed
+	// from the queue by a consumer.
+	Popped uint64
+
+	// Dropped is the total number of values that have been discarded
+	// due to the queue's Overflow policy.
+	Dropped uint64
+
+	// HighWater is the largest number of values that have been in the
+	// queue at once.
+	HighWater int
+
+	// Len is the number of values currently in the queue.
+	Len int
+}
+
+// Stats returns statistics about the queue.
+func (q *Queue[T]) Stats() (stats QueueStats) {
+	q.do(func(r *queueRunner[T]) {
+		stats = r.stats
+		stats.Len = r.buf.len()
+	})
+	return stats
+}
+
+// do runs f in the Queue's runner goroutine and waits for it to
+// return. It returns false without running f if the runner has
+// exited.
+func (q *Queue[T]) do(f func(*queueRunner[T])) bool {
+	q.init()
+	return runnerDo(q.req, q.exit, f)
+}
+
 type queueRunner[T any] struct {
-	add chan T
-	get chan T
-	all chan iter.Seq[T]
+	add  chan T
+	wait chan T
+	get  chan T
+	all  chan iter.Seq[T]
+	req  chan func(*queueRunner[T])
+	exit chan struct{}
+	left *[]T
+
+	cap      int
+	overflow Overflow
+	closing  bool
+
+	buf   queueBuffer[T]
+	stats QueueStats
 }
 
 func (q *queueRunner[T]) run(done <-chan struct{}) {
-	add := q.add
-	var get chan T
-	var all chan iter.Seq[T]
-
 	defer func() {
 		close(q.get)
 		close(q.all)
-		if add != nil {
+		if q.add != nil {
 			// Ensure that future attempts to send to the queue will fail.
-			close(add)
+			close(q.add)
+		}
+		if q.buf.len() > 0 {
+			*q.left = slices.Collect(q.buf.all())
 		}
+		close(q.exit)
 	}()
 
-	var s list.Single[T]
 	for {
+		add, wait := q.add, q.wait
+		switch {
+		case q.closing:
+			add, wait = nil, nil
+		case q.full() && q.overflow == OverflowBlock:
+			// Stop accepting new values so that pushers block.
+			add, wait = nil, nil
+		}
+
+		var get chan T
+		var all chan iter.Seq[T]
+		if q.buf.len() > 0 {
+			get = q.get
+			all = q.all
+		}
+
 		select {
 		case <-done:
 			return
 
+		case f := <-q.req:
+			if !runnerServe(done, q, f) {
+				return
+			}
+			if q.drained() {
+				return
+			}
+
 		case v, ok := <-add:
 			if !ok {
-				add = nil
+				q.add = nil
+				q.close()
+				if q.drained() {
+					return
+				}
 				continue
 			}
 
-			s.Enqueue(v)
-			get = q.get
-			all = q.all
+			q.push(v)
 
-		case get <- s.Peek():
-			ok := s.Pop()
-			if !ok {
-				if add == nil {
-					return
-				}
+		case v := <-wait:
+			q.push(v)
 
-				get = nil
+		case get <- q.buf.peek():
+			q.buf.pop()
+			q.stats.Popped++
+			if q.drained() {
+				return
 			}
 
-		case all <- s.All():
-			s = list.Single[T]{}
-			all = nil
-			get = nil
+		case all <- q.buf.all():
+			q.clear()
+			if q.drained() {
+				return
+			}
 		}
 	}
 }
+
+func (q *queueRunner[T]) full() bool {
+	return q.cap > 0 && q.buf.len() >= q.cap
+}
+
+// close marks the queue as closing. Values from pushers that are
+// already blocked are accepted, but no further values are.
+func (q *queueRunner[T]) close() {
+	q.closing = true
+	for {
+		select {
+		case v, ok := <-q.add:
+			if !ok {
+				q.add = nil
+				continue
+			}
+			q.push(v)
+		case v := <-q.wait:
+			q.push(v)
+		default:
+			return
+		}
+	}
+}
+
+// drained returns true if the queue is closing and has no values
+// left.
+func (q *queueRunner[T]) drained() bool {
+	return q.closing && q.buf.len() == 0
+}
+
+// push adds v to the queue, applying the overflow policy if the
+// queue is full. It returns false if v was not added.
+func (q *queueRunner[T]) push(v T) bool {
+	// Pushers that were blocked when the queue was closed are let
+	// through regardless of Cap.
+	if q.full() && !(q.closing && q.overflow == OverflowBlock) {
+		if q.overflow != OverflowDropOldest {
+			if q.overflow == OverflowDropNewest {
+				q.stats.Dropped++
+			}
+			return false
+		}
+
+		q.buf.pop()
+		q.stats.Dropped++
+	}
+
+	q.buf.push(v)
+	q.stats.Pushed++
+	q.stats.HighWater = max(q.stats.HighWater, q.buf.len())
+	return true
+}
+
+// clear removes all values from the queue, counting them as popped.
+func (q *queueRunner[T]) clear() {
+	q.stats.Popped += uint64(q.buf.len())
+	q.buf.clear()
+}
+
+// queueBuffer is the storage used by a queueRunner, determining the
+// order in which values are yielded.
+type queueBuffer[T any] interface {
+	len() int
+	push(T)
+
+	// peek returns the next value to be yielded, or the zero value if
+	// the buffer is empty.
+	peek() T
+	pop()
+
+	// all returns an iterator over the current contents in the order
+	// that they would be yielded. It must remain valid after clear is
+	// called.
+	all() iter.Seq[T]
+	clear()
+}
+
+// listBuffer is a FIFO queueBuffer.
+type listBuffer[T any] struct {
+	s list.Single[T]
+	n int
+}
+
+func (b *listBuffer[T]) len() int { return b.n }
+
+func (b *listBuffer[T]) push(v T) {
+	b.s.Enqueue(v)
+	b.n++
+}
+
+func (b *listBuffer[T]) peek() T { return b.s.Peek() }
+
+func (b *listBuffer[T]) pop() {
+	if b.n > 0 {
+		b.s.Pop()
+		b.n--
+	}
+}
+
+func (b *listBuffer[T]) all() iter.Seq[T] {
+	// Iterate over a copy so that the iterator is unaffected by clear.
+	s := b.s
+	return s.All()
+}
+
+func (b *listBuffer[T]) clear() {
+	b.s = list.Single[T]{}
+	b.n = 0
+}
@@ -0,0 +1,283 @@
+package xsync_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"slices"
+	"testing"
+	"time"
+
+	"deedles.dev/xsync"
+)
+
+func TestQueue(t *testing.T) {
+	var q xsync.Queue[int]
+	defer q.Stop()
+
+	for i := range 3 {
+		q.Push() <- i
+	}
+	for i := range 3 {
+		if v := <-q.Pop(); v != i {
+			t.Fatalf("expected %v but got %v", i, v)
+		}
+	}
+
+	q.Push() <- 3
+	close(q.Push())
+	if v := <-q.Pop(); v != 3 {
+		t.Fatal(v)
+	}
+	if v, ok := <-q.Pop(); ok {
+		t.Fatalf("expected closed channel but got %v", v)
+	}
+}
+
+func TestQueueBounded(t *testing.T) {
+	tests := []struct {
+		name     string
+		overflow xsync.Overflow
+		want     []int
+		err      error
+	}{
+		{name: "Block", overflow: xsync.OverflowBlock, want: []int{0, 1}, err: xsync.ErrQueueFull},
+		{name: "DropNewest", overflow: xsync.OverflowDropNewest, want: []int{0, 1}, err: xsync.ErrQueueFull},
+		{name: "DropOldest", overflow: xsync.OverflowDropOldest, want: []int{1, 2}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q := xsync.Queue[int]{Cap: 2, Overflow: test.overflow}
+			defer q.Stop()
+
+			for i := range 2 {
+				q.Push() <- i
+			}
+			if err := q.TryPush(2); err != test.err {
+				t.Fatalf("expected %v but got %v", test.err, err)
+			}
+			for _, want := range test.want {
+				if v := <-q.Pop(); v != want {
+					t.Fatalf("expected %v but got %v", want, v)
+				}
+			}
+		})
+	}
+}
+
+func TestQueueBoundedBlock(t *testing.T) {
+	q := xsync.Queue[int]{Cap: 1}
+	defer q.Stop()
+
+	q.Push() <- 1
+	select {
+	case q.Push() <- 2:
+		t.Fatal("push to full queue did not block")
+	default:
+	}
+
+	<-q.Pop()
+	q.Push() <- 2
+	if v := <-q.Pop(); v != 2 {
+		t.Fatal(v)
+	}
+
+	q.Stop()
+	if err := q.TryPush(3); err != xsync.ErrQueueClosed {
+		t.Fatalf("expected ErrQueueClosed but got %v", err)
+	}
+}
+
+func TestQueuePushContext(t *testing.T) {
+	q := xsync.Queue[int]{Cap: 1}
+	if err := q.PushContext(t.Context(), 1); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancelCause(t.Context())
+	cancel(io.EOF)
+	if err := q.PushContext(ctx, 2); !errors.Is(err, io.EOF) {
+		t.Fatalf("expected io.EOF but got %v", err)
+	}
+
+	errc := make(chan error)
+	go func() { errc <- q.PushContext(t.Context(), 3) }()
+	q.Stop()
+	if err := <-errc; err != xsync.ErrQueueClosed {
+		t.Fatalf("expected ErrQueueClosed but got %v", err)
+	}
+	if vals := q.Undelivered(); !slices.Equal(vals, []int{1}) {
+		t.Fatal(vals)
+	}
+}
+
+func TestQueueIntrospection(t *testing.T) {
+	var q xsync.Queue[int]
+	defer q.Stop()
+
+	if _, ok := q.Peek(); ok {
+		t.Fatal("peeked value in empty queue")
+	}
+	if vals := q.Drain(); len(vals) != 0 {
+		t.Fatal(vals)
+	}
+
+	for i := range 3 {
+		q.Push() <- i
+	}
+	if n := q.Len(); n != 3 {
+		t.Fatal(n)
+	}
+	if v, ok := q.Peek(); !ok || v != 0 {
+		t.Fatal(v, ok)
+	}
+	<-q.Pop()
+	q.Push() <- 3
+	if vals := q.Drain(); !slices.Equal(vals, []int{1, 2, 3}) {
+		t.Fatal(vals)
+	}
+
+	stats := q.Stats()
+	want := xsync.QueueStats{Pushed: 4, Popped: 4, HighWater: 3}
+	if stats != want {
+		t.Fatalf("expected %+v but got %+v", want, stats)
+	}
+}
+
+func TestQueueAll(t *testing.T) {
+	var q xsync.Queue[int]
+	defer q.Stop()
+
+	for i := range 3 {
+		q.Push() <- i
+	}
+	if vals := slices.Collect(<-q.All()); !slices.Equal(vals, []int{0, 1, 2}) {
+		t.Fatal(vals)
+	}
+	if n := q.Len(); n != 0 {
+		t.Fatal(n)
+	}
+}
+
+func TestQueueBatches(t *testing.T) {
+	var q xsync.Queue[int]
+	defer q.Stop()
+
+	batches := q.Batches(3, 10*time.Millisecond)
+	for i := range 4 {
+		q.Push() <- i
+	}
+	if b := <-batches; !slices.Equal(b, []int{0, 1, 2}) {
+		t.Fatal(b)
+	}
+
+	start := time.Now()
+	if b := <-batches; !slices.Equal(b, []int{3}) {
+		t.Fatal(b)
+	}
+	if d := time.Since(start); d < 5*time.Millisecond {
+		t.Fatalf("partial batch sent after only %v", d)
+	}
+
+	q.Push() <- 4
+	close(q.Push())
+	if b := <-batches; !slices.Equal(b, []int{4}) {
+		t.Fatal(b)
+	}
+	if b, ok := <-batches; ok {
+		t.Fatalf("expected closed channel but got %v", b)
+	}
+}
+
+func TestQueueBatchesUnlimited(t *testing.T) {
+	var q xsync.Queue[int]
+	defer q.Stop()
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic")
+		}
+	}()
+	q.Batches(0, 0)
+}
+
+func TestQueueClose(t *testing.T) {
+	var q xsync.Queue[int]
+	for i := range 3 {
+		q.Push() <- i
+	}
+
+	q.Close()
+	q.Close()
+	if err := q.TryPush(3); err != xsync.ErrQueueClosed {
+		t.Fatalf("expected ErrQueueClosed but got %v", err)
+	}
+	select {
+	case <-q.Done():
+		t.Fatal("queue stopped before it was drained")
+	default:
+	}
+
+	var got []int
+	for v := range q.Pop() {
+		got = append(got, v)
+	}
+	<-q.Done()
+	if !slices.Equal(got, []int{0, 1, 2}) {
+		t.Fatal(got)
+	}
+	if vals := q.Undelivered(); len(vals) != 0 {
+		t.Fatal(vals)
+	}
+}
+
+func TestQueueCloseBlocked(t *testing.T) {
+	q := xsync.Queue[int]{Cap: 1}
+	q.Push() <- 1
+
+	sent := make(chan struct{})
+	go func() {
+		defer close(sent)
+		q.Push() <- 2
+	}()
+	// Give the pusher time to block on the full queue.
+	time.Sleep(10 * time.Millisecond)
+
+	q.Close()
+	<-sent
+
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+	defer cancel()
+	if err := q.PushContext(ctx, 3); err != context.DeadlineExceeded {
+		t.Fatalf("expected push after Close to time out but got %v", err)
+	}
+
+	var got []int
+	for v := range q.Pop() {
+		got = append(got, v)
+	}
+	if !slices.Equal(got, []int{1, 2}) {
+		t.Fatal(got)
+	}
+	if err := q.PushContext(t.Context(), 4); err != xsync.ErrQueueClosed {
+		t.Fatalf("expected ErrQueueClosed but got %v", err)
+	}
+}
+
+func TestQueueUndelivered(t *testing.T) {
+	var q xsync.Queue[int]
+	for i := range 3 {
+		q.Push() <- i
+	}
+	<-q.Pop()
+
+	if vals := q.Undelivered(); vals != nil {
+		t.Fatalf("got undelivered values from running queue: %v", vals)
+	}
+
+	q.Stop()
+	<-q.Done()
+	if vals := q.Undelivered(); !slices.Equal(vals, []int{1, 2}) {
+		t.Fatal(vals)
+	}
+}
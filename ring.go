@@ -0,0 +1,184 @@
+package xsync
+
+import (
+	"context"
+	"math/bits"
+	"sync/atomic"
+)
+
+// A Ring is a bounded, lock-free, multi-producer, multi-consumer FIFO
+// queue backed by a ring buffer. Unlike [Queue], it does not use a
+// background goroutine, making it better suited for high-throughput
+// use at the cost of having a fixed capacity.
+//
+// A Ring must be created with [NewRing] and must not be copied after
+// first use.
+type Ring[T any] struct {
+	_ noCopy
+
+	mask  uint64
+	slots []ringSlot[T]
+
+	// notEmpty and notFull wake blocked calls to Pop and Push. Each
+	// holds at most one pending signal, which a woken waiter passes on
+	// after it succeeds so that no waiter misses a change.
+	notEmpty chan struct{}
+	notFull  chan struct{}
+
+	_    [64]byte
+	head atomic.Uint64
+	_    [56]byte
+	tail atomic.Uint64
+	_    [56]byte
+}
+
+type ringSlot[T any] struct {
+	seq atomic.Uint64
+	val T
+}
+
+// NewRing returns a new Ring that can hold at least size values. The
+// actual capacity is size rounded up to the next power of two. It
+// panics if size is less than 1.
+func NewRing[T any](size int) *Ring[T] {
+	if size < 1 {
+		panic("ring size must be at least 1")
+	}
+
+	n := uint64(1) << bits.Len64(uint64(size-1))
+	r := Ring[T]{
+		mask:     n - 1,
+		slots:    make([]ringSlot[T], n),
+		notEmpty: make(chan struct{}, 1),
+		notFull:  make(chan struct{}, 1),
+	}
+	for i := range r.slots {
+		r.slots[i].seq.Store(uint64(i))
+	}
+	return &r
+}
+
+// Cap returns the number of values that the Ring can hold.
+func (r *Ring[T]) Cap() int {
+	return len(r.slots)
+}
+
+// Len returns the number of values in the Ring. Because the Ring can
+// be modified concurrently, the result is only an approximation.
+func (r *Ring[T]) Len() int {
+	head, tail := r.head.Load(), r.tail.Load()
+	if tail < head {
+		return 0
+	}
+	return int(min(tail-head, uint64(len(r.slots))))
+}
+
+// TryPush adds v to the Ring if there is room for it. It returns
+// false if the Ring is full.
+func (r *Ring[T]) TryPush(v T) bool {
+	pos := r.tail.Load()
+	for {
+		slot := &r.slots[pos&r.mask]
+		seq := slot.seq.Load()
+		switch diff := int64(seq - pos); {
+		case diff == 0:
+			if !r.tail.CompareAndSwap(pos, pos+1) {
+				pos = r.tail.Load()
+				continue
+			}
+
+			slot.val = v
+			slot.seq.Store(pos + 1)
+			signal(r.notEmpty)
+			return true
+
+		case diff < 0:
+			return false
+
+		default:
+			pos = r.tail.Load()
+		}
+	}
+}
+
+// TryPop removes and returns the value at the front of the Ring. If
+// the Ring is empty, it returns false as the second return.
+func (r *Ring[T]) TryPop() (v T, ok bool) {
+	pos := r.head.Load()
+	for {
+		slot := &r.slots[pos&r.mask]
+		seq := slot.seq.Load()
+		switch diff := int64(seq - (pos + 1)); {
+		case diff == 0:
+			if !r.head.CompareAndSwap(pos, pos+1) {
+				pos = r.head.Load()
+				continue
+			}
+
+			v = slot.val
+			var zero T
+			slot.val = zero
+			slot.seq.Store(pos + r.mask + 1)
+			signal(r.notFull)
+			return v, true
+
+		case diff < 0:
+			return v, false
+
+		default:
+			pos = r.head.Load()
+		}
+	}
+}
+
+// Push adds v to the Ring, blocking until there is room for it. If
+// ctx is canceled first, it returns the context's cause.
+func (r *Ring[T]) Push(ctx context.Context, v T) error {
+	var woken bool
+	for !r.TryPush(v) {
+		select {
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		case <-r.notFull:
+			woken = true
+		}
+	}
+
+	if woken {
+		// Pass on the wakeup in case there is still room.
+		signal(r.notFull)
+	}
+	return nil
+}
+
+// Pop removes and returns the value at the front of the Ring,
+// blocking until there is one. If ctx is canceled first, it returns
+// the context's cause.
+func (r *Ring[T]) Pop(ctx context.Context) (T, error) {
+	var woken bool
+	for {
+		v, ok := r.TryPop()
+		if ok {
+			if woken {
+				// Pass on the wakeup in case there are more values.
+				signal(r.notEmpty)
+			}
+			return v, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return v, context.Cause(ctx)
+		case <-r.notEmpty:
+			woken = true
+		}
+	}
+}
+
+// signal performs a non-blocking send to c.
+func signal(c chan struct{}) {
+	select {
+	case c <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,114 @@
+package xsync_test
+
+import (
+	"context"
+	"io"
+	"sync"
+	"testing"
+
+	"deedles.dev/xsync"
+)
+
+func TestRing(t *testing.T) {
+	r := xsync.NewRing[int](3)
+	if c := r.Cap(); c != 4 {
+		t.Fatal(c)
+	}
+
+	for i := range 4 {
+		if !r.TryPush(i) {
+			t.Fatalf("push %v failed", i)
+		}
+	}
+	if r.TryPush(4) {
+		t.Fatal("pushed to full ring")
+	}
+	if n := r.Len(); n != 4 {
+		t.Fatal(n)
+	}
+
+	ctx, cancel := context.WithCancelCause(t.Context())
+	cancel(io.EOF)
+	if err := r.Push(ctx, 4); err != io.EOF {
+		t.Fatalf("expected EOF but got %v", err)
+	}
+
+	for i := range 4 {
+		v, ok := r.TryPop()
+		if !ok || v != i {
+			t.Fatal(v, ok)
+		}
+	}
+	if v, ok := r.TryPop(); ok {
+		t.Fatalf("popped %v from empty ring", v)
+	}
+	if _, err := r.Pop(ctx); err != io.EOF {
+		t.Fatalf("expected EOF but got %v", err)
+	}
+}
+
+func TestRingConcurrent(t *testing.T) {
+	const producers, consumers, per = 4, 4, 1000
+
+	r := xsync.NewRing[int](8)
+	var wg sync.WaitGroup
+	for p := range producers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range per {
+				if err := r.Push(t.Context(), p*per+i); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+
+	results := make(chan int, producers*per)
+	for range consumers {
+		go func() {
+			for range producers * per / consumers {
+				v, err := r.Pop(t.Context())
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				results <- v
+			}
+		}()
+	}
+
+	wg.Wait()
+	seen := make([]bool, producers*per)
+	for range producers * per {
+		v := <-results
+		if seen[v] {
+			t.Fatalf("value %v received twice", v)
+		}
+		seen[v] = true
+	}
+}
+
+func BenchmarkRing(b *testing.B) {
+	r := xsync.NewRing[int](1024)
+	ctx := context.Background()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			r.Push(ctx, 1)
+			r.Pop(ctx)
+		}
+	})
+}
+
+func BenchmarkQueue(b *testing.B) {
+	var q xsync.Queue[int]
+	defer q.Stop()
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			q.Push() <- 1
+			<-q.Pop()
+		}
+	})
+}
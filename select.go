@@ -1,6 +1,10 @@
 package xsync
 
-import "reflect"
+import (
+	"context"
+	"reflect"
+	"time"
+)
 
 // SelectCase represents either a send or receive on a channel, or a
 // default case with no channel associated.
@@ -13,17 +17,140 @@ type SelectCase interface {
 
 // Select performs a select operation on the provided cases.
 func Select(cases ...SelectCase) {
-	rcases := make([]reflect.SelectCase, 0, len(cases))
+	selectDone(nil, cases)
+}
+
+// SelectIndex is like [Select] but returns the index of the case that
+// was selected.
+func SelectIndex(cases ...SelectCase) int {
+	return selectDone(nil, cases)
+}
+
+// SelectContext is like [SelectIndex] but also returns early if ctx
+// is canceled before any of the cases are selected. In that case, it
+// returns -1 and the context's cause.
+func SelectContext(ctx context.Context, cases ...SelectCase) (int, error) {
+	i := selectDone(ctx.Done(), cases)
+	if i < 0 {
+		return i, context.Cause(ctx)
+	}
+	return i, nil
+}
+
+// RecvAny receives from whichever of chans is ready first and returns
+// the index of that channel along with the results of the receive. If
+// chans is empty, RecvAny blocks forever.
+func RecvAny[T any](chans []<-chan T) (idx int, v T, ok bool) {
+	cases := make([]SelectCase, 0, len(chans))
+	for _, c := range chans {
+		cases = append(cases, RecvOK(c, func(rv T, rok bool) { v, ok = rv, rok }))
+	}
+
+	idx = SelectIndex(cases...)
+	return idx, v, ok
+}
+
+// selectDone selects over cases as well as done. If done is selected,
+// it returns -1.
+func selectDone(done <-chan struct{}, cases []SelectCase) int {
+	if i, ok := selectNative(done, cases); ok {
+		return i
+	}
+
+	rcases := make([]reflect.SelectCase, 0, len(cases)+1)
 	for _, c := range cases {
 		rcases = append(rcases, c.rcase())
 	}
+	if done != nil {
+		rcases = append(rcases, reflect.SelectCase{
+			Dir:  reflect.SelectRecv,
+			Chan: reflect.ValueOf(done),
+		})
+	}
 
 	i, v, ok := reflect.Select(rcases)
+	release(cases)
+	if i == len(cases) {
+		return -1
+	}
+
 	cases[i].do(v, ok)
+	return i
+}
+
+// nativeCase is implemented by cases that can be selected using a
+// regular select statement instead of reflection.
+type nativeCase interface {
+	// selectNative selects over the case's channel and done. If poll
+	// is true, it returns -1 instead of blocking if neither is ready.
+	// Otherwise, it returns 0 if the case was selected and 1 if done
+	// was.
+	selectNative(done <-chan struct{}, poll bool) int
+}
+
+// selectNative attempts to perform a select over a small number of
+// cases without using reflection. If the cases are not in a form that
+// it can handle, it returns false.
+func selectNative(done <-chan struct{}, cases []SelectCase) (int, bool) {
+	switch len(cases) {
+	case 1:
+		c, ok := cases[0].(nativeCase)
+		if !ok {
+			return 0, false
+		}
+		if c.selectNative(done, false) == 1 {
+			return -1, true
+		}
+		return 0, true
+
+	case 2:
+		if done != nil {
+			return 0, false
+		}
+
+		for i := range 2 {
+			c, ok := cases[i].(nativeCase)
+			if !ok {
+				continue
+			}
+
+			switch other := cases[1-i].(type) {
+			case defaultCase:
+				if c.selectNative(nil, true) < 0 {
+					other.do(reflect.Value{}, false)
+					return 1 - i, true
+				}
+				return i, true
+
+			case doneCase:
+				if c.selectNative(other.done, false) == 1 {
+					other.do(reflect.Value{}, false)
+					return 1 - i, true
+				}
+				return i, true
+			}
+		}
+	}
+
+	return 0, false
+}
+
+// releaser is implemented by cases that hold resources, such as
+// timers, between a call to rcase and the end of the select.
+type releaser interface {
+	release()
+}
+
+func release(cases []SelectCase) {
+	for _, c := range cases {
+		if r, ok := c.(releaser); ok {
+			r.release()
+		}
+	}
 }
 
 type recvCase[T any] struct {
-	c reflect.Value
+	c <-chan T
 	f func(T)
 }
 
@@ -31,7 +158,7 @@ type recvCase[T any] struct {
 // the channel c. If f is not nil, it will be called with the result
 // of the receive if the receive is selected.
 func Recv[T any](c <-chan T, f func(T)) SelectCase {
-	return recvCase[T]{c: reflect.ValueOf(c), f: f}
+	return recvCase[T]{c: c, f: f}
 }
 
 func (c recvCase[T]) Dir() reflect.SelectDir {
@@ -41,7 +168,7 @@ func (c recvCase[T]) Dir() reflect.SelectDir {
 func (c recvCase[T]) rcase() reflect.SelectCase {
 	return reflect.SelectCase{
 		Dir:  c.Dir(),
-		Chan: c.c,
+		Chan: reflect.ValueOf(c.c),
 	}
 }
 
@@ -51,8 +178,36 @@ func (c recvCase[T]) do(v reflect.Value, ok bool) {
 	}
 }
 
+func (c recvCase[T]) selectNative(done <-chan struct{}, poll bool) int {
+	if poll {
+		select {
+		case v := <-c.c:
+			c.call(v)
+			return 0
+		case <-done:
+			return 1
+		default:
+			return -1
+		}
+	}
+
+	select {
+	case v := <-c.c:
+		c.call(v)
+		return 0
+	case <-done:
+		return 1
+	}
+}
+
+func (c recvCase[T]) call(v T) {
+	if c.f != nil {
+		c.f(v)
+	}
+}
+
 type recvOKCase[T any] struct {
-	c reflect.Value
+	c <-chan T
 	f func(T, bool)
 }
 
@@ -60,7 +215,7 @@ type recvOKCase[T any] struct {
 // the channel c. If f is not nil, it will be called with the result
 // of the receive if the receive is selected.
 func RecvOK[T any](c <-chan T, f func(T, bool)) SelectCase {
-	return recvOKCase[T]{c: reflect.ValueOf(c), f: f}
+	return recvOKCase[T]{c: c, f: f}
 }
 
 func (c recvOKCase[T]) Dir() reflect.SelectDir {
@@ -70,7 +225,7 @@ func (c recvOKCase[T]) Dir() reflect.SelectDir {
 func (c recvOKCase[T]) rcase() reflect.SelectCase {
 	return reflect.SelectCase{
 		Dir:  c.Dir(),
-		Chan: c.c,
+		Chan: reflect.ValueOf(c.c),
 	}
 }
 
@@ -80,16 +235,44 @@ func (c recvOKCase[T]) do(v reflect.Value, ok bool) {
 	}
 }
 
+func (c recvOKCase[T]) selectNative(done <-chan struct{}, poll bool) int {
+	if poll {
+		select {
+		case v, ok := <-c.c:
+			c.call(v, ok)
+			return 0
+		case <-done:
+			return 1
+		default:
+			return -1
+		}
+	}
+
+	select {
+	case v, ok := <-c.c:
+		c.call(v, ok)
+		return 0
+	case <-done:
+		return 1
+	}
+}
+
+func (c recvOKCase[T]) call(v T, ok bool) {
+	if c.f != nil {
+		c.f(v, ok)
+	}
+}
+
 type sendCase[T any] struct {
-	c reflect.Value
-	v reflect.Value
+	c chan<- T
+	v T
 	f func()
 }
 
 // Send returns a SelectCase representing a send of v to the channel
 // c. If f is not nil, it will be called if the send is selected.
 func Send[T any](c chan<- T, v T, f func()) SelectCase {
-	return sendCase[T]{c: reflect.ValueOf(c), v: reflect.ValueOf(v), f: f}
+	return sendCase[T]{c: c, v: v, f: f}
 }
 
 func (c sendCase[T]) Dir() reflect.SelectDir {
@@ -99,8 +282,8 @@ func (c sendCase[T]) Dir() reflect.SelectDir {
 func (c sendCase[T]) rcase() reflect.SelectCase {
 	return reflect.SelectCase{
 		Dir:  c.Dir(),
-		Chan: c.c,
-		Send: c.v,
+		Chan: reflect.ValueOf(c.c),
+		Send: reflect.ValueOf(c.v),
 	}
 }
 
@@ -110,6 +293,28 @@ func (c sendCase[T]) do(v reflect.Value, ok bool) {
 	}
 }
 
+func (c sendCase[T]) selectNative(done <-chan struct{}, poll bool) int {
+	if poll {
+		select {
+		case c.c <- c.v:
+			c.do(reflect.Value{}, false)
+			return 0
+		case <-done:
+			return 1
+		default:
+			return -1
+		}
+	}
+
+	select {
+	case c.c <- c.v:
+		c.do(reflect.Value{}, false)
+		return 0
+	case <-done:
+		return 1
+	}
+}
+
 type defaultCase struct {
 	f func()
 }
@@ -135,3 +340,268 @@ func (c defaultCase) do(reflect.Value, bool) {
 		c.f()
 	}
 }
+
+type doneCase struct {
+	done <-chan struct{}
+	f    func()
+}
+
+func (c doneCase) Dir() reflect.SelectDir {
+	return reflect.SelectRecv
+}
+
+func (c doneCase) rcase() reflect.SelectCase {
+	return reflect.SelectCase{
+		Dir:  c.Dir(),
+		Chan: reflect.ValueOf(c.done),
+	}
+}
+
+func (c doneCase) do(reflect.Value, bool) {
+	if c.f != nil {
+		c.f()
+	}
+}
+
+func (c doneCase) selectNative(done <-chan struct{}, poll bool) int {
+	if poll {
+		select {
+		case <-c.done:
+			c.do(reflect.Value{}, false)
+			return 0
+		case <-done:
+			return 1
+		default:
+			return -1
+		}
+	}
+
+	select {
+	case <-c.done:
+		c.do(reflect.Value{}, false)
+		return 0
+	case <-done:
+		return 1
+	}
+}
+
+// Await returns a SelectCase that is selected when the future f
+// completes. If fn is not nil, it will be called with the value of
+// the future if the case is selected.
+func Await[T any](f *Future[T], fn func(T)) SelectCase {
+	return doneCase{
+		done: f.Done(),
+		f: func() {
+			if fn != nil {
+				fn(f.Get())
+			}
+		},
+	}
+}
+
+// AwaitErr is like [Await] but for fallible futures.
+func AwaitErr[T any](f *ErrFuture[T], fn func(T, error)) SelectCase {
+	return doneCase{
+		done: f.Done(),
+		f: func() {
+			if fn != nil {
+				fn(f.Get())
+			}
+		},
+	}
+}
+
+// Stopped returns a SelectCase that is selected when the Stopper s
+// is stopped. If f is not nil, it will be called if the case is
+// selected.
+func Stopped(s *Stopper, f func()) SelectCase {
+	return doneCase{done: s.Done(), f: f}
+}
+
+// Canceled returns a SelectCase that is selected when ctx is
+// canceled. If f is not nil, it will be called with the context's
+// cause if the case is selected.
+func Canceled(ctx context.Context, f func(error)) SelectCase {
+	return doneCase{
+		done: ctx.Done(),
+		f: func() {
+			if f != nil {
+				f(context.Cause(ctx))
+			}
+		},
+	}
+}
+
+type timerCase struct {
+	at    func() time.Time
+	f     func(time.Time)
+	timer *time.Timer
+}
+
+// After returns a SelectCase that is selected once d has elapsed
+// since the start of the select. If f is not nil, it will be called
+// with the time at which the timer fired if the case is selected.
+//
+// The timer is stopped when the select finishes, regardless of which
+// case was selected.
+func After(d time.Duration, f func(time.Time)) SelectCase {
+	return &timerCase{
+		at: func() time.Time { return time.Now().Add(d) },
+		f:  f,
+	}
+}
+
+// At is like [After] but is selected once the time t is reached.
+func At(t time.Time, f func(time.Time)) SelectCase {
+	return &timerCase{
+		at: func() time.Time { return t },
+		f:  f,
+	}
+}
+
+func (c *timerCase) Dir() reflect.SelectDir {
+	return reflect.SelectRecv
+}
+
+func (c *timerCase) rcase() reflect.SelectCase {
+	c.timer = time.NewTimer(time.Until(c.at()))
+	return reflect.SelectCase{
+		Dir:  c.Dir(),
+		Chan: reflect.ValueOf(c.timer.C),
+	}
+}
+
+func (c *timerCase) do(v reflect.Value, ok bool) {
+	if c.f != nil {
+		c.f(v.Interface().(time.Time))
+	}
+}
+
+func (c *timerCase) release() {
+	if c.timer != nil {
+		c.timer.Stop()
+		c.timer = nil
+	}
+}
+
+// A Case is a statically typed select case for use with [Select2],
+// [Select3], and [Select4]. Unlike a [SelectCase], selecting over
+// Cases never requires reflection, regardless of how the cases are
+// combined. The zero value of Case is never selected.
+type Case[T any] struct {
+	recv  <-chan T
+	send  chan<- T
+	v     T
+	recvf func(T, bool)
+	sendf func()
+}
+
+// CaseRecv returns a Case representing a single-value receive from
+// the channel c. If f is not nil, it will be called with the result
+// of the receive if the receive is selected.
+func CaseRecv[T any](c <-chan T, f func(T)) Case[T] {
+	var recvf func(T, bool)
+	if f != nil {
+		recvf = func(v T, ok bool) { f(v) }
+	}
+	return Case[T]{recv: c, recvf: recvf}
+}
+
+// CaseRecvOK returns a Case representing a two-value receive from the
+// channel c. If f is not nil, it will be called with the result of
+// the receive if the receive is selected.
+func CaseRecvOK[T any](c <-chan T, f func(T, bool)) Case[T] {
+	return Case[T]{recv: c, recvf: f}
+}
+
+// CaseSend returns a Case representing a send of v to the channel c.
+// If f is not nil, it will be called if the send is selected.
+func CaseSend[T any](c chan<- T, v T, f func()) Case[T] {
+	return Case[T]{send: c, v: v, sendf: f}
+}
+
+func (c Case[T]) received(v T, ok bool) {
+	if c.recvf != nil {
+		c.recvf(v, ok)
+	}
+}
+
+func (c Case[T]) sent() {
+	if c.sendf != nil {
+		c.sendf()
+	}
+}
+
+// Select2 blocks until one of the two cases can proceed, performs it,
+// and returns its index. It is equivalent to [SelectIndex] but uses a
+// native select statement.
+func Select2[T1, T2 any](c1 Case[T1], c2 Case[T2]) int {
+	select {
+	case v, ok := <-c1.recv:
+		c1.received(v, ok)
+		return 0
+	case c1.send <- c1.v:
+		c1.sent()
+		return 0
+	case v, ok := <-c2.recv:
+		c2.received(v, ok)
+		return 1
+	case c2.send <- c2.v:
+		c2.sent()
+		return 1
+	}
+}
+
+// Select3 is like [Select2] but for three cases.
+func Select3[T1, T2, T3 any](c1 Case[T1], c2 Case[T2], c3 Case[T3]) int {
+	select {
+	case v, ok := <-c1.recv:
+		c1.received(v, ok)
+		return 0
+	case c1.send <- c1.v:
+		c1.sent()
+		return 0
+	case v, ok := <-c2.recv:
+		c2.received(v, ok)
+		return 1
+	case c2.send <- c2.v:
+		c2.sent()
+		return 1
+	case v, ok := <-c3.recv:
+		c3.received(v, ok)
+		return 2
+	case c3.send <- c3.v:
+		c3.sent()
+		return 2
+	}
+}
+
+// Select4 is like [Select2] but for four cases.
+func Select4[T1, T2, T3, T4 any](c1 Case[T1], c2 Case[T2], c3 Case[T3], c4 Case[T4]) int {
+	select {
+	case v, ok := <-c1.recv:
+		c1.received(v, ok)
+		return 0
+	case c1.send <- c1.v:
+		c1.sent()
+		return 0
+	case v, ok := <-c2.recv:
+		c2.received(v, ok)
+		return 1
+	case c2.send <- c2.v:
+		c2.sent()
+		return 1
+	case v, ok := <-c3.recv:
+		c3.received(v, ok)
+		return 2
+	case c3.send <- c3.v:
+		c3.sent()
+		return 2
+	case v, ok := <-c4.recv:
+		c4.received(v, ok)
+		return 3
+	case c4.send <- c4.v:
+		c4.sent()
+		return 3
+	}
+}
@@ -1,7 +1,13 @@
 package xsync_test
 
 import (
+	"context"
+	"io"
+	"reflect"
+	"slices"
+	"strconv"
 	"testing"
+	"time"
 
 	"deedles.dev/xsync"
 )
@@ -27,3 +33,328 @@ func TestSelect(t *testing.T) {
 		t.Fatalf("expected 3 but got %v", got)
 	}
 }
+
+func TestSelectFuture(t *testing.T) {
+	f, complete := xsync.NewFuture[int]()
+	var s xsync.Stopper
+
+	complete(3)
+	var got int
+	xsync.Select(
+		xsync.Await(f, func(v int) { got = v }),
+		xsync.Stopped(&s, func() { t.Fatal("stopper selected") }),
+	)
+	if got != 3 {
+		t.Fatalf("expected 3 but got %v", got)
+	}
+
+	s.Stop()
+	var stopped bool
+	xsync.Select(
+		xsync.Await(xsync.Lazy(func() int { select {} }), nil),
+		xsync.Stopped(&s, func() { stopped = true }),
+	)
+	if !stopped {
+		t.Fatal("stopper not selected")
+	}
+}
+
+func TestSelectContext(t *testing.T) {
+	ctx, cancel := context.WithCancelCause(t.Context())
+	cancel(io.EOF)
+
+	var got error
+	xsync.Select(
+		xsync.Canceled(ctx, func(err error) { got = err }),
+		xsync.After(time.Hour, func(time.Time) { t.Fatal("timer selected") }),
+	)
+	if got != io.EOF {
+		t.Fatalf("expected EOF but got %v", got)
+	}
+}
+
+func TestSelectAfter(t *testing.T) {
+	var fired bool
+	xsync.Select(
+		xsync.Recv(make(chan int), nil),
+		xsync.After(time.Millisecond, func(time.Time) { fired = true }),
+	)
+	if !fired {
+		t.Fatal("timer not selected")
+	}
+
+	fired = false
+	xsync.Select(
+		xsync.At(time.Now().Add(-time.Second), func(time.Time) { fired = true }),
+	)
+	if !fired {
+		t.Fatal("timer not selected")
+	}
+}
+
+func TestSelectIndex(t *testing.T) {
+	c := make(chan int, 1)
+	tests := []struct {
+		name  string
+		setup func()
+		want  int
+	}{
+		{name: "Default", setup: func() {}, want: 1},
+		{name: "Recv", setup: func() { c <- 1 }, want: 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.setup()
+			i := xsync.SelectIndex(xsync.Recv(c, nil), xsync.Default(nil))
+			if i != test.want {
+				t.Fatalf("expected %v but got %v", test.want, i)
+			}
+		})
+	}
+}
+
+func TestSelectContextCancel(t *testing.T) {
+	ctx, cancel := context.WithCancelCause(t.Context())
+	cancel(io.EOF)
+
+	i, err := xsync.SelectContext(ctx, xsync.Recv(make(chan int), nil))
+	if i != -1 || err != io.EOF {
+		t.Fatal(i, err)
+	}
+
+	c := make(chan int, 1)
+	c <- 1
+	i, err = xsync.SelectContext(t.Context(), xsync.Recv(c, nil))
+	if i != 0 || err != nil {
+		t.Fatal(i, err)
+	}
+}
+
+func TestSelectNative(t *testing.T) {
+	c := make(chan int, 1)
+	var s xsync.Stopper
+
+	var got []string
+	sel := func() int {
+		return xsync.SelectIndex(
+			xsync.Recv(c, func(v int) { got = append(got, "recv") }),
+			xsync.Stopped(&s, func() { got = append(got, "stopped") }),
+		)
+	}
+
+	c <- 1
+	if i := sel(); i != 0 {
+		t.Fatal(i)
+	}
+	s.Stop()
+	if i := sel(); i != 1 {
+		t.Fatal(i)
+	}
+
+	i := xsync.SelectIndex(xsync.Send(c, 2, func() { got = append(got, "send") }), xsync.Default(nil))
+	if i != 0 {
+		t.Fatal(i)
+	}
+	i = xsync.SelectIndex(xsync.Default(nil), xsync.Send(c, 3, nil))
+	if i != 0 {
+		t.Fatal(i)
+	}
+	if v := <-c; v != 2 {
+		t.Fatal(v)
+	}
+
+	if len(got) != 3 || got[0] != "recv" || got[1] != "stopped" || got[2] != "send" {
+		t.Fatal(got)
+	}
+}
+
+func TestSelectTyped(t *testing.T) {
+	ints := make(chan int, 1)
+	strs := make(chan string, 1)
+	closed := make(chan struct{})
+	close(closed)
+
+	var got []string
+	i := xsync.Select2(
+		xsync.CaseRecv(ints, func(v int) { got = append(got, "int") }),
+		xsync.CaseSend(strs, "a", func() { got = append(got, "send") }),
+	)
+	if i != 1 {
+		t.Fatal(i)
+	}
+
+	i = xsync.Select3(
+		xsync.CaseSend(strs, "b", nil),
+		xsync.CaseRecv(ints, nil),
+		xsync.CaseRecv(strs, func(v string) { got = append(got, v) }),
+	)
+	if i != 2 {
+		t.Fatal(i)
+	}
+
+	ints <- 3
+	i = xsync.Select4(
+		xsync.Case[string]{},
+		xsync.CaseRecv[string](nil, nil),
+		xsync.CaseRecv(ints, func(v int) { got = append(got, strconv.Itoa(v)) }),
+		xsync.Case[bool]{},
+	)
+	if i != 2 {
+		t.Fatal(i)
+	}
+
+	i = xsync.Select4(
+		xsync.Case[int]{},
+		xsync.Case[int]{},
+		xsync.Case[int]{},
+		xsync.CaseRecvOK(closed, func(_ struct{}, ok bool) { got = append(got, strconv.FormatBool(ok)) }),
+	)
+	if i != 3 {
+		t.Fatal(i)
+	}
+
+	if !slices.Equal(got, []string{"send", "a", "3", "false"}) {
+		t.Fatal(got)
+	}
+}
+
+func BenchmarkSelect(b *testing.B) {
+	c := make(chan int, 1)
+	var s xsync.Stopper
+
+	b.Run("RecvDefault", func(b *testing.B) {
+		b.Run("Native", func(b *testing.B) {
+			for b.Loop() {
+				c <- 1
+				xsync.Select(xsync.Recv(c, nil), xsync.Default(nil))
+			}
+		})
+		b.Run("Reflect", func(b *testing.B) {
+			for b.Loop() {
+				c <- 1
+				reflectSelect(
+					reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(c)},
+					reflect.SelectCase{Dir: reflect.SelectDefault},
+				)
+			}
+		})
+	})
+
+	b.Run("RecvStopped", func(b *testing.B) {
+		b.Run("Native", func(b *testing.B) {
+			for b.Loop() {
+				c <- 1
+				xsync.Select(xsync.Recv(c, nil), xsync.Stopped(&s, nil))
+			}
+		})
+		b.Run("Reflect", func(b *testing.B) {
+			for b.Loop() {
+				c <- 1
+				reflectSelect(
+					reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(c)},
+					reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(s.Done())},
+				)
+			}
+		})
+	})
+
+	c2 := make(chan int)
+	full := make(chan string, 1)
+	full <- ""
+
+	b.Run("Two", func(b *testing.B) {
+		b.Run("Typed", func(b *testing.B) {
+			for b.Loop() {
+				c <- 1
+				xsync.Select2(xsync.CaseRecv(c, nil), xsync.CaseRecv(c2, nil))
+			}
+		})
+		b.Run("Select", func(b *testing.B) {
+			for b.Loop() {
+				c <- 1
+				xsync.Select(xsync.Recv(c, nil), xsync.Recv(c2, nil))
+			}
+		})
+		b.Run("Reflect", func(b *testing.B) {
+			for b.Loop() {
+				c <- 1
+				reflectSelect(
+					reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(c)},
+					reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(c2)},
+				)
+			}
+		})
+	})
+
+	b.Run("Three", func(b *testing.B) {
+		b.Run("Typed", func(b *testing.B) {
+			for b.Loop() {
+				c <- 1
+				xsync.Select3(xsync.CaseRecv(c, nil), xsync.CaseRecv(c2, nil), xsync.CaseRecv(s.Done(), nil))
+			}
+		})
+		b.Run("Select", func(b *testing.B) {
+			for b.Loop() {
+				c <- 1
+				xsync.Select(xsync.Recv(c, nil), xsync.Recv(c2, nil), xsync.Stopped(&s, nil))
+			}
+		})
+		b.Run("Reflect", func(b *testing.B) {
+			for b.Loop() {
+				c <- 1
+				reflectSelect(
+					reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(c)},
+					reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(c2)},
+					reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(s.Done())},
+				)
+			}
+		})
+	})
+
+	b.Run("Four", func(b *testing.B) {
+		b.Run("Typed", func(b *testing.B) {
+			for b.Loop() {
+				c <- 1
+				xsync.Select4(
+					xsync.CaseRecv(c, nil),
+					xsync.CaseRecvOK(c2, nil),
+					xsync.CaseSend(full, "", nil),
+					xsync.CaseRecv(s.Done(), nil),
+				)
+			}
+		})
+		b.Run("Select", func(b *testing.B) {
+			for b.Loop() {
+				c <- 1
+				xsync.Select(
+					xsync.Recv(c, nil),
+					xsync.RecvOK(c2, nil),
+					xsync.Send(full, "", nil),
+					xsync.Stopped(&s, nil),
+				)
+			}
+		})
+		b.Run("Reflect", func(b *testing.B) {
+			for b.Loop() {
+				c <- 1
+				reflectSelect(
+					reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(c)},
+					reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(c2)},
+					reflect.SelectCase{Dir: reflect.SelectSend, Chan: reflect.ValueOf(full), Send: reflect.ValueOf("")},
+					reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(s.Done())},
+				)
+			}
+		})
+	})
+}
+
+// reflectSelect mimics the implementation of Select before it had a
+// native fast path.
+func reflectSelect(cases ...reflect.SelectCase) {
+	rcases := make([]reflect.SelectCase, 0, len(cases))
+	rcases = append(rcases, cases...)
+	_, v, _ := reflect.Select(rcases)
+	if v.IsValid() {
+		_ = v.Interface().(int)
+	}
+}
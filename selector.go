@@ -0,0 +1,218 @@
+package xsync
+
+import (
+	"context"
+	"iter"
+	"reflect"
+)
+
+// A SelectHandle identifies a case that has been added to a
+// [Selector]. The zero value is never a valid handle.
+type SelectHandle uint64
+
+// A Selector performs select operations over a persistent set of
+// cases. Unlike [Select], it does not rebuild its internal state
+// every time that it is used, making it better suited for loops that
+// select over a large number of channels.
+//
+// A zero-value Selector is ready to use. A Selector is not safe for
+// concurrent use, but the functions associated with its cases may
+// modify it while it is selecting.
+type Selector struct {
+	// RemoveClosed, if true, causes receive cases to be removed from
+	// the Selector when they are selected because their channel was
+	// closed. This includes the cases returned by [Await], [Stopped],
+	// and [Canceled], which are only ever selected once their channels
+	// are closed.
+	RemoveClosed bool
+
+	next    SelectHandle
+	index   map[SelectHandle]int
+	handles []SelectHandle
+	cases   []SelectCase
+	rcases  []reflect.SelectCase
+
+	dynamic int
+	stop    bool
+}
+
+// Add adds c to the Selector and returns a handle to it.
+func (s *Selector) Add(c SelectCase) SelectHandle {
+	if s.index == nil {
+		s.index = make(map[SelectHandle]int)
+	}
+
+	s.next++
+	h := s.next
+	s.index[h] = len(s.cases)
+	s.handles = append(s.handles, h)
+	s.cases = append(s.cases, c)
+	s.rcases = append(s.rcases, s.rcase(c))
+
+	return h
+}
+
+// Remove removes the case identified by h from the Selector. It
+// returns false if there was no such case.
+func (s *Selector) Remove(h SelectHandle) bool {
+	i, ok := s.index[h]
+	if !ok {
+		return false
+	}
+
+	if r, ok := s.cases[i].(releaser); ok {
+		r.release()
+		s.dynamic--
+	}
+
+	last := len(s.cases) - 1
+	s.handles[i] = s.handles[last]
+	s.cases[i] = s.cases[last]
+	s.rcases[i] = s.rcases[last]
+	s.index[s.handles[i]] = i
+
+	s.handles[last] = 0
+	s.cases[last] = nil
+	s.rcases[last] = reflect.SelectCase{}
+	s.handles = s.handles[:last]
+	s.cases = s.cases[:last]
+	s.rcases = s.rcases[:last]
+	delete(s.index, h)
+
+	return true
+}
+
+// Replace replaces the case identified by h with c, keeping the same
+// handle. It returns false if there was no such case, in which case c
+// is not added.
+func (s *Selector) Replace(h SelectHandle, c SelectCase) bool {
+	i, ok := s.index[h]
+	if !ok {
+		return false
+	}
+
+	if r, ok := s.cases[i].(releaser); ok {
+		r.release()
+		s.dynamic--
+	}
+
+	s.cases[i] = c
+	s.rcases[i] = s.rcase(c)
+	return true
+}
+
+// rcase returns the reflect.SelectCase for c. Cases that hold
+// resources only get theirs at the start of each select, so a
+// placeholder is returned for them instead.
+func (s *Selector) rcase(c SelectCase) reflect.SelectCase {
+	if _, ok := c.(releaser); ok {
+		s.dynamic++
+		return reflect.SelectCase{}
+	}
+	return c.rcase()
+}
+
+// Len returns the number of cases in the Selector.
+func (s *Selector) Len() int {
+	return len(s.cases)
+}
+
+// Select performs a single select operation over the Selector's cases
+// and returns the handle of the case that was selected. If the
+// Selector has no cases, Select blocks forever.
+func (s *Selector) Select() SelectHandle {
+	return s.selectDone(nil)
+}
+
+// Stop causes a running call to Loop to return once the currently
+// selected case has been handled. It is intended to be called from
+// the function associated with a case.
+func (s *Selector) Stop() {
+	s.stop = true
+}
+
+// Loop repeatedly selects over the Selector's cases until either
+// Stop is called or ctx is canceled. In the latter case, the
+// context's cause is returned.
+func (s *Selector) Loop(ctx context.Context) error {
+	s.stop = false
+	for !s.stop {
+		if s.selectDone(ctx.Done()) == 0 {
+			return context.Cause(ctx)
+		}
+	}
+	return nil
+}
+
+// selectDone selects over the Selector's cases as well as done. If
+// done is selected, it returns 0.
+func (s *Selector) selectDone(done <-chan struct{}) SelectHandle {
+	if s.dynamic > 0 {
+		for i, c := range s.cases {
+			if _, ok := c.(releaser); ok {
+				s.rcases[i] = c.rcase()
+			}
+		}
+		defer s.releaseDynamic()
+	}
+
+	rcases := s.rcases
+	if done != nil {
+		rcases = append(rcases, reflect.SelectCase{
+			Dir:  reflect.SelectRecv,
+			Chan: reflect.ValueOf(done),
+		})
+	}
+
+	i, v, ok := reflect.Select(rcases)
+	if i == len(s.cases) {
+		return 0
+	}
+
+	h, c := s.handles[i], s.cases[i]
+	if s.RemoveClosed && !ok && c.Dir() == reflect.SelectRecv {
+		s.Remove(h)
+	}
+
+	c.do(v, ok)
+	return h
+}
+
+func (s *Selector) releaseDynamic() {
+	for _, c := range s.cases {
+		if r, ok := c.(releaser); ok {
+			r.release()
+		}
+	}
+}
+
+// Merge returns an iterator that yields values received from any of
+// chans along with the index of the channel that each was received
+// from. The iterator ends once all of chans have been closed or ctx
+// is canceled.
+func Merge[T any](ctx context.Context, chans ...<-chan T) iter.Seq2[int, T] {
+	return func(yield func(int, T) bool) {
+		var (
+			idx int
+			v   T
+			ok  bool
+		)
+
+		s := Selector{RemoveClosed: true}
+		for i, c := range chans {
+			s.Add(RecvOK(c, func(rv T, rok bool) { idx, v, ok = i, rv, rok }))
+		}
+
+		for s.Len() > 0 {
+			if s.selectDone(ctx.Done()) == 0 {
+				return
+			}
+			if !ok {
+				continue
+			}
+			if !yield(idx, v) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package xsync_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"slices"
+	"testing"
+	"time"
+
+	"deedles.dev/xsync"
+)
+
+func TestSelector(t *testing.T) {
+	c1 := make(chan int, 1)
+	c2 := make(chan int, 1)
+
+	var s xsync.Selector
+	var got []int
+	h1 := s.Add(xsync.Recv(c1, func(v int) { got = append(got, v) }))
+	h2 := s.Add(xsync.Recv(c2, func(v int) { got = append(got, -v) }))
+
+	c1 <- 1
+	if h := s.Select(); h != h1 {
+		t.Fatalf("expected %v but got %v", h1, h)
+	}
+	c2 <- 2
+	if h := s.Select(); h != h2 {
+		t.Fatalf("expected %v but got %v", h2, h)
+	}
+
+	if !s.Replace(h1, xsync.Recv(c1, func(v int) { got = append(got, v*10) })) {
+		t.Fatal("replace failed")
+	}
+	if !s.Remove(h2) || s.Remove(h2) {
+		t.Fatal("remove misbehaved")
+	}
+	if s.Len() != 1 {
+		t.Fatal(s.Len())
+	}
+
+	c1 <- 3
+	c2 <- 4
+	s.Select()
+	if !slices.Equal(got, []int{1, -2, 30}) {
+		t.Fatal(got)
+	}
+}
+
+func TestSelectorLoop(t *testing.T) {
+	c := make(chan int)
+	go func() {
+		defer close(c)
+		for i := range 3 {
+			c <- i
+		}
+	}()
+
+	s := xsync.Selector{RemoveClosed: true}
+	var got []int
+	s.Add(xsync.RecvOK(c, func(v int, ok bool) {
+		if !ok {
+			s.Stop()
+			return
+		}
+		got = append(got, v)
+	}))
+
+	err := s.Loop(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(got, []int{0, 1, 2}) {
+		t.Fatal(got)
+	}
+	if s.Len() != 0 {
+		t.Fatalf("closed channel not removed: %v", s.Len())
+	}
+
+	ctx, cancel := context.WithCancelCause(t.Context())
+	s.Add(xsync.After(time.Millisecond, func(time.Time) { cancel(io.EOF) }))
+	err = s.Loop(ctx)
+	if !errors.Is(err, io.EOF) {
+		t.Fatalf("expected EOF but got %v", err)
+	}
+}
+
+func TestRecvAny(t *testing.T) {
+	c := make(chan int, 1)
+	c <- 3
+	chans := []<-chan int{make(chan int), make(chan int), c}
+
+	i, v, ok := xsync.RecvAny(chans)
+	if i != 2 || v != 3 || !ok {
+		t.Fatal(i, v, ok)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	c1 := make(chan int)
+	c2 := make(chan int)
+	go func() {
+		defer close(c1)
+		defer close(c2)
+		c1 <- 1
+		c2 <- 2
+		c1 <- 3
+	}()
+
+	type pair struct{ i, v int }
+	var got []pair
+	for i, v := range xsync.Merge(t.Context(), c1, c2) {
+		got = append(got, pair{i, v})
+	}
+	if !slices.Equal(got, []pair{{0, 1}, {1, 2}, {0, 3}}) {
+		t.Fatal(got)
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	for range xsync.Merge(ctx, make(chan int)) {
+		t.Fatal("yielded after cancellation")
+	}
+}
@@ -1,6 +1,16 @@
 package xsync
 
-import "sync"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrStopped is returned by [Stopper.Err] once a Stopper has been
+// stopped.
+var ErrStopped = errors.New("stopped")
 
 // A Stopper provides a simple way to handle a done channel for
 // internal coordination. For coordination across API boundaries, it
@@ -8,28 +18,149 @@ import "sync"
 //
 // The zero value of a Stopper is ready to use.
 type Stopper struct {
-	done  chan struct{}
-	start sync.Once
-	stop  func()
+	start  sync.Once
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+
+	wg      sync.WaitGroup
+	running atomic.Int64
 }
 
 func (s *Stopper) init() {
+	s.initFrom(context.Background())
+}
+
+func (s *Stopper) initFrom(parent context.Context) {
 	s.start.Do(func() {
-		s.done = make(chan struct{})
-		s.stop = sync.OnceFunc(func() { close(s.done) })
+		s.ctx, s.cancel = context.WithCancelCause(parent)
 	})
 }
 
+// StopperFromContext returns a new Stopper that is stopped when ctx
+// is canceled. The Stopper's cause will be the cause of ctx.
+func StopperFromContext(ctx context.Context) *Stopper {
+	var s Stopper
+	s.initFrom(ctx)
+	return &s
+}
+
+// Child returns a new Stopper that is stopped when s is stopped,
+// inheriting its cause. Stopping the child has no effect on s.
+func (s *Stopper) Child() *Stopper {
+	s.init()
+	return StopperFromContext(s.ctx)
+}
+
+// Context returns a context derived from parent that is also
+// canceled when s is stopped, in which case the context's cause will
+// be the cause of s. As with [context.WithCancel], the returned
+// cancel function should be called once the context is no longer
+// needed in order to release the resources associated with it.
+func (s *Stopper) Context(parent context.Context) (context.Context, context.CancelFunc) {
+	s.init()
+
+	ctx, cancel := context.WithCancelCause(parent)
+	unregister := context.AfterFunc(s.ctx, func() { cancel(context.Cause(s.ctx)) })
+	context.AfterFunc(ctx, func() { unregister() })
+	return ctx, func() { cancel(context.Canceled) }
+}
+
 // Stop closes the Stoppers Done channel. It is safe to call more than
 // once.
 func (s *Stopper) Stop() {
+	s.StopCause(nil)
+}
+
+// StopCause is like [Stop] but records err as the reason that the
+// Stopper was stopped. If err is nil, the cause is set to
+// [ErrStopped]. Only the first call to Stop or StopCause has any
+// effect.
+func (s *Stopper) StopCause(err error) {
 	s.init()
-	s.stop()
+	if err == nil {
+		err = ErrStopped
+	}
+	s.cancel(err)
 }
 
 // Done returns a channel that is closed when the Stop method is
 // called. The channel can already be closed when this method returns.
 func (s *Stopper) Done() <-chan struct{} {
 	s.init()
-	return s.done
+	return s.ctx.Done()
+}
+
+// Stopped returns true if the Stopper has been stopped.
+func (s *Stopper) Stopped() bool {
+	return s.Err() != nil
+}
+
+// Err returns nil if the Stopper has not been stopped and
+// [ErrStopped] if it has.
+func (s *Stopper) Err() error {
+	s.init()
+	if s.ctx.Err() != nil {
+		return ErrStopped
+	}
+	return nil
+}
+
+// Cause returns nil if the Stopper has not been stopped. Otherwise,
+// it returns the error that was passed to StopCause, or [ErrStopped]
+// if there was none.
+func (s *Stopper) Cause() error {
+	s.init()
+	return context.Cause(s.ctx)
+}
+
+// AfterStop arranges for f to be called in its own goroutine after
+// the Stopper is stopped. If the Stopper has already been stopped, f
+// is called immediately in its own goroutine. Calling the returned
+// unregister function prevents f from being called if it has not
+// been already, in which case it returns true. Multiple calls to
+// AfterStop operate independently.
+//
+// AfterStop mirrors [context.AfterFunc].
+func (s *Stopper) AfterStop(f func()) (unregister func() bool) {
+	s.init()
+	return context.AfterFunc(s.ctx, f)
+}
+
+// Go runs f in a new goroutine that is tracked by the Stopper. f is
+// passed the Stopper's Done channel and should return once it is
+// closed. See [StopAndWait].
+func (s *Stopper) Go(f func(done <-chan struct{})) {
+	s.init()
+
+	s.running.Add(1)
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer s.running.Add(-1)
+
+		f(s.ctx.Done())
+	}()
+}
+
+// StopAndWait stops the Stopper and then waits for all goroutines
+// started with Go to return. If ctx is canceled before they have all
+// returned, it returns an error wrapping the context's cause that
+// reports how many of them are still running.
+//
+// Go should not be called concurrently with StopAndWait.
+func (s *Stopper) StopAndWait(ctx context.Context) error {
+	s.Stop()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%w: %v goroutines still running", context.Cause(ctx), s.running.Load())
+	}
 }
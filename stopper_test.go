@@ -0,0 +1,117 @@
+package xsync_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"deedles.dev/xsync"
+)
+
+func TestStopper(t *testing.T) {
+	var s xsync.Stopper
+	if s.Stopped() || s.Err() != nil || s.Cause() != nil {
+		t.Fatal("new stopper already stopped")
+	}
+
+	called := make(chan struct{})
+	s.AfterStop(func() { close(called) })
+	unregister := s.AfterStop(func() { t.Error("unregistered function called") })
+	if !unregister() {
+		t.Fatal("failed to unregister")
+	}
+
+	s.StopCause(io.EOF)
+	s.Stop()
+	<-s.Done()
+	<-called
+	if !s.Stopped() || s.Err() != xsync.ErrStopped || s.Cause() != io.EOF {
+		t.Fatal(s.Stopped(), s.Err(), s.Cause())
+	}
+
+	var s2 xsync.Stopper
+	s2.Stop()
+	if s2.Cause() != xsync.ErrStopped {
+		t.Fatal(s2.Cause())
+	}
+}
+
+func TestStopperChild(t *testing.T) {
+	var parent xsync.Stopper
+	c1 := parent.Child()
+	c2 := parent.Child()
+
+	c1.Stop()
+	if parent.Stopped() || c2.Stopped() {
+		t.Fatal("child stopped parent or sibling")
+	}
+
+	parent.StopCause(io.EOF)
+	<-c2.Done()
+	if c2.Cause() != io.EOF {
+		t.Fatal(c2.Cause())
+	}
+}
+
+func TestStopperContext(t *testing.T) {
+	var s xsync.Stopper
+	ctx, cancel := s.Context(t.Context())
+	defer cancel()
+	if ctx.Err() != nil {
+		t.Fatal(ctx.Err())
+	}
+
+	s.StopCause(io.EOF)
+	<-ctx.Done()
+	if context.Cause(ctx) != io.EOF {
+		t.Fatal(context.Cause(ctx))
+	}
+
+	var s3 xsync.Stopper
+	ctx, cancel = s3.Context(t.Context())
+	cancel()
+	s3.StopCause(io.EOF)
+	if context.Cause(ctx) != context.Canceled {
+		t.Fatal(context.Cause(ctx))
+	}
+
+	pctx, pcancel := context.WithCancelCause(t.Context())
+	s2 := xsync.StopperFromContext(pctx)
+	pcancel(io.ErrUnexpectedEOF)
+	<-s2.Done()
+	if s2.Cause() != io.ErrUnexpectedEOF {
+		t.Fatal(s2.Cause())
+	}
+}
+
+func TestStopperStopAndWait(t *testing.T) {
+	var s xsync.Stopper
+	exited := make(chan struct{}, 2)
+	for range 2 {
+		s.Go(func(done <-chan struct{}) {
+			<-done
+			exited <- struct{}{}
+		})
+	}
+
+	err := s.StopAndWait(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(exited) != 2 {
+		t.Fatalf("StopAndWait returned with %v goroutines running", 2-len(exited))
+	}
+
+	var s2 xsync.Stopper
+	release := make(chan struct{})
+	defer close(release)
+	s2.Go(func(<-chan struct{}) { <-release })
+
+	ctx, cancel := context.WithCancelCause(t.Context())
+	cancel(io.EOF)
+	err = s2.StopAndWait(ctx)
+	if !errors.Is(err, io.EOF) {
+		t.Fatalf("expected EOF but got %v", err)
+	}
+}
@@ -6,3 +6,42 @@ type noCopy struct{}
 
 func (*noCopy) Lock()   {}
 func (*noCopy) Unlock() {}
+
+// runnerDo sends f to a runner goroutine via req and waits for it to
+// return. It returns false without running f if the runner exits,
+// indicated by exit being closed, first.
+func runnerDo[R any](req chan<- func(R), exit <-chan struct{}, f func(R)) bool {
+	ran := make(chan struct{})
+	select {
+	case req <- func(r R) { defer close(ran); f(r) }:
+	case <-exit:
+		return false
+	}
+
+	select {
+	case <-ran:
+		return true
+	case <-exit:
+		select {
+		case <-ran:
+			return true
+		default:
+			return false
+		}
+	}
+}
+
+// runnerServe runs a request that a runner goroutine received from
+// its request channel. If done has been closed in the meantime, the
+// request is not run and runnerServe returns false to indicate that
+// the runner should exit.
+func runnerServe[R any](done <-chan struct{}, r R, f func(R)) bool {
+	select {
+	case <-done:
+		// Don't service requests made after the runner was stopped.
+		return false
+	default:
+		f(r)
+		return true
+	}
+}